@@ -0,0 +1,500 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Cross-ledger anchors
+//
+// Each ledger is one operator's chain, so on its own nothing stops an insider
+// from rewriting its history. With anchoring enabled, every ledger signs its
+// tip block hash each interval and submits it to the peer ledger (auth to
+// req and req to auth), which records it as a host-signed "anchor"
+// transaction. Once the anchor is sealed on the peer, the anchored history
+// cannot be rewritten without rewriting the peer too. /anchor/status checks
+// both directions: this ledger's chain against the anchors the peer holds
+// for it, and the peer's chain against the anchors held here. Anchor
+// transactions do not move ownership and are skipped by the state tree,
+// finality and NFT history.
+
+// Anchor is a ledger host's signed statement of its chain's tip.
+type Anchor struct {
+	Ledger      string `json:"ledger"` // Anchored ledger, "auth" or "req"
+	Tenant      string `json:"tenant,omitempty"`
+	GenesisHash string `json:"genesis_hash"`
+	BlockIndex  int    `json:"block_index"`
+	BlockHash   string `json:"block_hash"`
+	IssuedAt    int64  `json:"issued_at"` // Unix seconds
+	Host        string `json:"host"`      // Host wallet that signed the anchor
+	Signature   string `json:"signature"` // Over the anchor's signing bytes
+}
+
+// AnchorConfig configures anchoring with the peer ledger.
+type AnchorConfig struct {
+	PeerURL   string        // Base URL of the peer ledger; empty only accepts the peer's anchors
+	PeerHosts []string      // Peer host wallets whose anchors are accepted
+	Interval  time.Duration // Time between anchors of this ledger's tip
+}
+
+// Anchoring settings, set once by StartAnchoring.
+var (
+	anchorPeerURL   string
+	anchorPeerHosts []string
+	anchorClient    = &http.Client{Timeout: 10 * time.Second}
+)
+
+// StartAnchoring accepts anchors from the configured peer hosts and, when a
+// peer URL is given, anchors the tip of every ledger on the peer each
+// config.Interval.
+func StartAnchoring(config AnchorConfig) error {
+	var hosts []string
+	for _, host := range config.PeerHosts {
+		if host = strings.TrimSpace(host); host == "" {
+			continue
+		}
+		if _, err := publicKeyFromAddress(host); err != nil {
+			return fmt.Errorf("anchor peer wallet %s: %w", host, err)
+		}
+		hosts = append(hosts, host)
+	}
+	if len(hosts) == 0 {
+		return errors.New("no anchor peer wallets given")
+	}
+	anchorPeerHosts = hosts
+	anchorPeerURL = strings.TrimRight(config.PeerURL, "/")
+	if anchorPeerURL == "" {
+		return nil
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			for _, bc := range allLedgers() {
+				if err := bc.publishAnchor(); err != nil {
+					anchorsPublished.Inc("failed")
+					log.Warn("Failed to anchor on peer ledger", "tenant", bc.tenant, "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// signingBytes returns the JSON encoding of a without its signature.
+func (a Anchor) signingBytes() []byte {
+	a.Signature = ""
+	b, _ := json.Marshal(a)
+	return b
+}
+
+// anchorError is the error for an anchor the ledger will not record.
+func anchorError(format string, args ...interface{}) error {
+	return &APIError{http.StatusForbidden, codeAnchor, fmt.Sprintf(format, args...)}
+}
+
+// publishAnchor signs bc's tip and records it on the peer ledger, unless
+// nothing needs anchoring.
+func (bc *Blockchain) publishAnchor() error {
+	bc.mutex.RLock()
+	tip := bc.Chain[len(bc.Chain)-1]
+	genesisHash := Hash(bc.Chain[0])
+	pending := bc.unanchored()
+	bc.mutex.RUnlock()
+	if !pending {
+		return nil
+	}
+
+	anchor := Anchor{Ledger: ledgerID, Tenant: bc.tenant, GenesisHash: genesisHash, BlockIndex: tip.Index,
+		BlockHash: Hash(tip), IssuedAt: time.Now().Unix(), Host: bc.HostWallet}
+	signature, err := bc.hostSign(anchor.signingBytes())
+	if err != nil {
+		return err
+	}
+	anchor.Signature = signature
+
+	var response struct {
+		Receipt Receipt `json:"receipt"`
+	}
+	if err := bc.peerCall(http.MethodPost, "/anchor/submit", anchor, &response); err != nil {
+		return err
+	}
+
+	bc.mutex.Lock()
+	bc.anchored = max(bc.anchored, tip.Index)
+	bc.mutex.Unlock()
+	anchorsPublished.Inc("accepted")
+	log.Info("Anchored block on peer ledger", "tenant", bc.tenant, "block_index", tip.Index,
+		"peer_tx_hash", response.Receipt.TxHash)
+	return nil
+}
+
+// unanchored reports whether blocks added since the last accepted anchor
+// need anchoring. Blocks holding only the peer's anchors do not, or two idle
+// ledgers would keep anchoring each other's anchors; they are covered by the
+// next anchor. Callers must hold bc.mutex.
+func (bc *Blockchain) unanchored() bool {
+	for _, block := range bc.Chain[bc.anchored:] {
+		if block.Pruned || block.TxCount == 0 {
+			return true
+		}
+		for _, tx := range block.Transactions {
+			if tx.Anchor == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// peerCall sends body as JSON to path on bc's counterpart on the peer ledger
+// and decodes the response into out.
+func (bc *Blockchain) peerCall(method, path string, body, out interface{}) error {
+	if anchorPeerURL == "" {
+		return errors.New("no anchor peer URL configured")
+	}
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, anchorPeerURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if bc.tenant != "" {
+		req.Header.Set(TenantHeader, bc.tenant)
+	}
+
+	resp, err := anchorClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("peer %s returned %s: %s", path, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// RecordAnchor checks an anchor submitted by the peer ledger and records it.
+// Resubmitting the latest anchor returns its receipt again.
+func (bc *Blockchain) RecordAnchor(anchor Anchor) (Receipt, error) {
+	switch {
+	case len(anchorPeerHosts) == 0:
+		return Receipt{}, anchorError("anchoring is not enabled")
+	case anchor.Ledger == "" || anchor.Ledger == ledgerID:
+		return Receipt{}, anchorError("anchor is not from the peer ledger")
+	case anchor.Tenant != bc.tenant:
+		return Receipt{}, anchorError("anchor is for tenant %q, not %q", anchor.Tenant, bc.tenant)
+	case anchor.BlockIndex < 1 || anchor.BlockHash == "" || anchor.GenesisHash == "":
+		return Receipt{}, anchorError("anchor has no block")
+	case !slices.Contains(anchorPeerHosts, anchor.Host):
+		return Receipt{}, anchorError("anchor host %s is not a trusted peer host", anchor.Host)
+	case !verifyAddressSignature(anchor.Host, anchor.signingBytes(), anchor.Signature):
+		return Receipt{}, anchorError("anchor signature does not verify")
+	}
+
+	bc.mutex.Lock()
+	tx, err := bc.recordAnchor(anchor)
+	bc.mutex.Unlock()
+	if err != nil {
+		return Receipt{}, err
+	}
+	return bc.Receipt(TxHash(tx))
+}
+
+// recordAnchor adds a transaction for anchor, or returns the transaction of
+// the latest anchor if it is the same block. An anchor that contradicts the
+// latest one is refused and reported as a conflict. Callers must hold
+// bc.mutex for writing.
+func (bc *Blockchain) recordAnchor(anchor Anchor) (Transaction, error) {
+	if n := len(bc.anchors); n > 0 {
+		last := bc.anchors[n-1]
+		var conflict string
+		switch {
+		case anchor.Ledger != last.Anchor.Ledger || anchor.GenesisHash != last.Anchor.GenesisHash:
+			conflict = "anchor is for a different chain than the previous anchor"
+		case anchor.BlockIndex < last.Anchor.BlockIndex:
+			conflict = fmt.Sprintf("block %d is behind the previously anchored block %d", anchor.BlockIndex, last.Anchor.BlockIndex)
+		case anchor.BlockIndex == last.Anchor.BlockIndex && anchor.BlockHash != last.Anchor.BlockHash:
+			conflict = fmt.Sprintf("block %d does not match its previous anchor", anchor.BlockIndex)
+		case anchor.BlockIndex == last.Anchor.BlockIndex:
+			return last, nil
+		}
+		if conflict != "" {
+			anchorConflicts.Inc()
+			bc.emitEvent("anchor.conflict", "", map[string]string{
+				"ledger": anchor.Ledger, "block_index": fmt.Sprint(anchor.BlockIndex), "reason": conflict,
+			})
+			log.Warn("Peer ledger anchor conflicts with its history", "ledger", anchor.Ledger,
+				"tenant", bc.tenant, "reason", conflict)
+			return Transaction{}, &APIError{http.StatusConflict, codeAnchorConflict, conflict}
+		}
+	}
+
+	anchorBytes, _ := json.Marshal(anchor)
+	signature, err := bc.hostSign(append([]byte("anchor:"), anchorBytes...))
+	if err != nil {
+		return Transaction{}, err
+	}
+	tx := Transaction{Sender: bc.HostWallet, Recipient: bc.HostWallet, Signature: signature, Type: "anchor",
+		Signer: bc.HostWallet, Anchor: &anchor}
+	bc.anchors = append(bc.anchors, tx)
+	receipt := bc.addTransaction(tx)
+	bc.emitEvent("anchor.recorded", "", map[string]string{
+		"ledger": anchor.Ledger, "block_index": fmt.Sprint(anchor.BlockIndex), "block_hash": anchor.BlockHash,
+		"tx_hash": receipt.TxHash,
+	})
+	return tx, nil
+}
+
+// AnchorEntry is an anchor recorded on a ledger, with its receipt there.
+type AnchorEntry struct {
+	Anchor  Anchor  `json:"anchor"`
+	Receipt Receipt `json:"receipt"`
+}
+
+// Anchors returns the peer's anchors recorded on bc, oldest first.
+func (bc *Blockchain) Anchors() []AnchorEntry {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	pending := make(map[string]bool, len(bc.CurrentTransactions))
+	for _, tx := range bc.CurrentTransactions {
+		if tx.Anchor != nil {
+			pending[TxHash(tx)] = true
+		}
+	}
+	entries := make([]AnchorEntry, 0, len(bc.anchors))
+	for _, tx := range bc.anchors {
+		hash := TxHash(tx)
+		receipt := Receipt{TxHash: hash, Type: tx.Type, Status: "pending"}
+		if sealed, ok := bc.txIndex[hash]; ok && !pending[hash] {
+			receipt.Status = "sealed"
+			receipt.BlockIndex = sealed.blockIndex
+			receipt.Confirmations = len(bc.Chain) - sealed.blockIndex + 1
+		}
+		entries = append(entries, AnchorEntry{Anchor: *tx.Anchor, Receipt: receipt})
+	}
+	return entries
+}
+
+// BlockHashes returns the chain height and the hashes of the blocks at
+// indexes. Indexes past the tip are left out.
+func (bc *Blockchain) BlockHashes(indexes []int) (int, map[int]string) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	hashes := make(map[int]string, len(indexes))
+	for _, index := range indexes {
+		if index >= 1 && index <= len(bc.Chain) {
+			hashes[index] = Hash(bc.Chain[index-1])
+		}
+	}
+	return len(bc.Chain), hashes
+}
+
+// AnchorCheck is the result of checking one ledger's chain against the
+// anchors of it held by the other ledger.
+type AnchorCheck struct {
+	Ledger      string       `json:"ledger"`              // Ledger whose chain was checked
+	Status      string       `json:"status"`              // "consistent", "rewritten", "unanchored" or "unavailable"
+	Anchors     int          `json:"anchors"`             // Anchors checked
+	Height      int          `json:"height,omitempty"`    // Blocks in the checked chain
+	Latest      *AnchorEntry `json:"latest,omitempty"`    // Newest anchor and its receipt on the ledger holding it
+	BlocksSince int          `json:"blocks_since_anchor"` // Blocks added after the newest anchor
+	Rewritten   []int        `json:"rewritten,omitempty"` // Anchored block indexes whose hash no longer matches
+	Error       string       `json:"error,omitempty"`     // Why the check could not run
+}
+
+// AnchorStatus reports whether either ledger's history has changed since
+// it was anchored on the other.
+type AnchorStatus struct {
+	Local     AnchorCheck `json:"local"` // This ledger against the anchors the peer holds
+	Peer      AnchorCheck `json:"peer"`  // The peer against the anchors this ledger holds
+	CheckedAt time.Time   `json:"checked_at"`
+}
+
+// compare checks entries against hashes, the checked chain's block hashes
+// by index, and sets the result on c.
+func (c *AnchorCheck) compare(entries []AnchorEntry, height int, hashes map[int]string) {
+	c.Anchors = len(entries)
+	c.Height = height
+	for _, entry := range entries {
+		a := entry.Anchor
+		if hashes[1] != a.GenesisHash || hashes[a.BlockIndex] != a.BlockHash {
+			c.Rewritten = append(c.Rewritten, a.BlockIndex)
+		}
+	}
+	if len(entries) > 0 {
+		latest := entries[len(entries)-1]
+		c.Latest = &latest
+		c.BlocksSince = max(0, height-latest.Anchor.BlockIndex)
+	}
+	switch {
+	case len(entries) == 0:
+		c.Status = "unanchored"
+	case len(c.Rewritten) > 0:
+		c.Status = "rewritten"
+	default:
+		c.Status = "consistent"
+	}
+}
+
+// anchorIndexes returns the genesis index and the block indexes of entries.
+func anchorIndexes(entries []AnchorEntry) []int {
+	indexes := []int{1}
+	for _, entry := range entries {
+		indexes = append(indexes, entry.Anchor.BlockIndex)
+	}
+	slices.Sort(indexes)
+	return slices.Compact(indexes)
+}
+
+// checkLocal checks bc's chain against the anchors the peer ledger holds for
+// it. Only anchors signed by bc's host wallet count.
+func (bc *Blockchain) checkLocal() AnchorCheck {
+	check := AnchorCheck{Ledger: ledgerID}
+	var response struct {
+		Anchors []AnchorEntry `json:"anchors"`
+	}
+	if err := bc.peerCall(http.MethodGet, "/anchor/list", nil, &response); err != nil {
+		check.Status, check.Error = "unavailable", err.Error()
+		return check
+	}
+
+	var entries []AnchorEntry
+	for _, entry := range response.Anchors {
+		a := entry.Anchor
+		if a.Ledger == ledgerID && a.Host == bc.HostWallet && verifyAddressSignature(a.Host, a.signingBytes(), a.Signature) {
+			entries = append(entries, entry)
+		}
+	}
+	height, hashes := bc.BlockHashes(anchorIndexes(entries))
+	check.compare(entries, height, hashes)
+	return check
+}
+
+// checkPeer checks the peer ledger's chain against its anchors held by bc.
+func (bc *Blockchain) checkPeer() AnchorCheck {
+	entries := bc.Anchors()
+	check := AnchorCheck{}
+	if len(entries) == 0 {
+		check.compare(nil, 0, nil)
+		return check
+	}
+	check.Ledger = entries[0].Anchor.Ledger
+
+	var response struct {
+		Height int            `json:"height"`
+		Hashes map[int]string `json:"hashes"`
+	}
+	request := map[string][]int{"indexes": anchorIndexes(entries)}
+	if err := bc.peerCall(http.MethodPost, "/anchor/hashes", request, &response); err != nil {
+		check.Anchors = len(entries)
+		check.Status, check.Error = "unavailable", err.Error()
+		return check
+	}
+	check.compare(entries, response.Height, response.Hashes)
+	return check
+}
+
+// AnchorStatus checks both ledgers against the anchors of them held by the
+// other.
+func (bc *Blockchain) AnchorStatus() AnchorStatus {
+	status := AnchorStatus{Local: bc.checkLocal(), Peer: bc.checkPeer(), CheckedAt: time.Now().UTC()}
+	for _, check := range []AnchorCheck{status.Local, status.Peer} {
+		if check.Status == "rewritten" {
+			log.Warn("Ledger history differs from its anchors", "ledger", check.Ledger, "tenant", bc.tenant,
+				"blocks", check.Rewritten)
+		}
+	}
+	return status
+}
+
+// AnchorSubmitHandler records an anchor from the peer ledger: POST Anchor.
+func AnchorSubmitHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var anchor Anchor
+	if err := json.NewDecoder(r.Body).Decode(&anchor); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	receipt, err := ledgerFor(r).RecordAnchor(anchor)
+	if err != nil {
+		status, _ := errorBodyFor(err)
+		http.Error(w, err.Error(), status)
+		log.Error("Failed to record anchor", "ledger", anchor.Ledger, "block_index", anchor.BlockIndex, "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]Receipt{"receipt": receipt})
+}
+
+// AnchorListHandler lists the peer's anchors recorded on this ledger.
+func AnchorListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]AnchorEntry{"anchors": ledgerFor(r).Anchors()})
+}
+
+// AnchorHashesHandler returns the chain height and the hashes of the
+// requested blocks, so the peer can check its anchors: POST {indexes}.
+func AnchorHashesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Indexes []int `json:"indexes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	height, hashes := ledgerFor(r).BlockHashes(req.Indexes)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"height": height, "hashes": hashes})
+}
+
+// AnchorStatusHandler reports whether either ledger's history has been
+// rewritten since it was last anchored on the other.
+func AnchorStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ledgerFor(r).AnchorStatus())
+}
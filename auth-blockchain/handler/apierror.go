@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// APIError is a ledger error with a stable, machine-readable code. The /v1
+// API reports it in a JSON envelope; the older endpoints send its message as
+// plain text.
+type APIError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Errors the ledger operations return. The messages match the plain-text
+// errors the unversioned endpoints have always sent.
+var (
+	ErrNFTNotFound  = &APIError{http.StatusNotFound, "NFT_NOT_FOUND", "NFT does not exist"}
+	ErrNotOwner     = &APIError{http.StatusForbidden, "NOT_OWNER", "sender not authorized"}
+	ErrBadSignature = &APIError{http.StatusUnauthorized, "BAD_SIGNATURE", "invalid signature"}
+	ErrBurned       = &APIError{http.StatusGone, "BURNED", "NFT has been burned"}
+	ErrExpired      = &APIError{http.StatusGone, "EXPIRED", "NFT has expired"}
+	ErrUnconfirmed  = &APIError{http.StatusConflict, "UNCONFIRMED", "NFT does not have enough confirmations"}
+	ErrTxNotFound   = &APIError{http.StatusNotFound, "TX_NOT_FOUND", "transaction not found"}
+	ErrNFTQuota     = &APIError{http.StatusTooManyRequests, "QUOTA_EXCEEDED", "tenant NFT quota exceeded"}
+	ErrWalletQuota  = &APIError{http.StatusTooManyRequests, "QUOTA_EXCEEDED", "tenant wallet quota exceeded"}
+	ErrNFTFrozen    = &APIError{http.StatusLocked, "FROZEN", "NFT is frozen"}
+	ErrWalletFrozen = &APIError{http.StatusLocked, "FROZEN", "wallet is frozen"}
+)
+
+// Codes for failures that are not ledger errors.
+const (
+	codeBadRequest       = "BAD_REQUEST"
+	codeUnknownTenant    = "UNKNOWN_TENANT"
+	codePolicyViolation  = "POLICY_VIOLATION"
+	codeClassRule        = "CLASS_RULE"
+	codeBadNonce         = "BAD_NONCE"
+	codeAuthBurn         = "AUTH_BURN"
+	codeAnchor           = "ANCHOR"
+	codeAnchorConflict   = "ANCHOR_CONFLICT"
+	codeForbidden        = "FORBIDDEN"
+	codeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	codeInternal         = "INTERNAL"
+)
+
+// writeJSON sends v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// errorBody is the error member of the /v1 envelope.
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	RuleID  string `json:"rule_id,omitempty"` // Set for POLICY_VIOLATION
+}
+
+// writeError sends err in the /v1 error envelope. Policy violations are
+// reported with their rule ID; other errors that are not an APIError are
+// reported as BAD_REQUEST.
+func writeError(w http.ResponseWriter, err error) {
+	status, body := errorBodyFor(err)
+	writeJSON(w, status, map[string]errorBody{"error": body})
+}
+
+// errorBodyFor returns the status and /v1 error body for err.
+func errorBodyFor(err error) (int, errorBody) {
+	if violation, ok := asPolicyViolation(err); ok {
+		return http.StatusForbidden, errorBody{Code: codePolicyViolation, Message: violation.Error(), RuleID: violation.RuleID}
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = &APIError{http.StatusBadRequest, codeBadRequest, err.Error()}
+	}
+	return apiErr.Status, errorBody{Code: apiErr.Code, Message: apiErr.Message}
+}
+
+// allowMethod wraps a /v1 handler so it only serves method and rejects
+// anything else with 405 and an Allow header.
+func allowMethod(method string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, &APIError{http.StatusMethodNotAllowed, codeMethodNotAllowed, "method " + r.Method + " not allowed"})
+			return
+		}
+		h(w, r)
+	}
+}
@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// segmentBlocks is the number of block bodies written to one archive segment
+// before a new segment is started.
+const segmentBlocks = 1000
+
+// archiveLocation points at one block body inside a segment file.
+type archiveLocation struct {
+	segment string
+	offset  int64
+	length  int
+}
+
+// blockArchive stores pruned block bodies in append-only segment files. Each
+// segment holds one JSON encoded block per line.
+type blockArchive struct {
+	mu        sync.Mutex
+	dir       string
+	current   *os.File
+	count     int   // Blocks in the current segment
+	size      int64 // Bytes in the current segment
+	locations map[int]archiveLocation
+}
+
+// openArchive opens the segments in dir, indexing any blocks already there.
+func openArchive(dir string) (*blockArchive, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	a := &blockArchive{dir: dir, locations: make(map[int]archiveLocation)}
+
+	segments, err := filepath.Glob(filepath.Join(dir, "segment-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(segments)
+	for _, segment := range segments {
+		if err := a.indexSegment(segment); err != nil {
+			return nil, fmt.Errorf("failed to index %s: %w", segment, err)
+		}
+	}
+	return a, nil
+}
+
+func (a *blockArchive) indexSegment(segment string) error {
+	f, err := os.Open(segment)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var offset int64
+	count := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A partial trailing line is a torn write and is ignored.
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var header BlockHeader
+		if err := json.Unmarshal(line, &header); err != nil {
+			return fmt.Errorf("corrupt record at offset %d: %w", offset, err)
+		}
+		a.locations[header.Index] = archiveLocation{segment: segment, offset: offset, length: len(line)}
+		offset += int64(len(line))
+		count++
+	}
+
+	// Keep appending to the last segment if it still has room.
+	if count < segmentBlocks {
+		if a.current != nil {
+			a.current.Close()
+		}
+		a.current, err = os.OpenFile(segment, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		if err := a.current.Truncate(offset); err != nil {
+			return err
+		}
+		a.count, a.size = count, offset
+	}
+	return nil
+}
+
+// append writes a full block to the current segment and fsyncs it.
+func (a *blockArchive) append(block Block) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, exists := a.locations[block.Index]; exists {
+		return nil
+	}
+	if a.current == nil || a.count >= segmentBlocks {
+		if err := a.rollSegment(block.Index); err != nil {
+			return err
+		}
+	}
+
+	record, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	record = append(record, '\n')
+	if _, err := a.current.Write(record); err != nil {
+		return err
+	}
+	if err := a.current.Sync(); err != nil {
+		return err
+	}
+
+	a.locations[block.Index] = archiveLocation{segment: a.current.Name(), offset: a.size, length: len(record)}
+	a.size += int64(len(record))
+	a.count++
+	return nil
+}
+
+func (a *blockArchive) rollSegment(firstIndex int) error {
+	if a.current != nil {
+		if err := a.current.Close(); err != nil {
+			return err
+		}
+	}
+	name := filepath.Join(a.dir, fmt.Sprintf("segment-%010d.jsonl", firstIndex))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	a.current, a.count, a.size = f, 0, 0
+	return nil
+}
+
+// read loads the full block with the given index from its segment.
+func (a *blockArchive) read(index int) (Block, error) {
+	a.mu.Lock()
+	loc, ok := a.locations[index]
+	a.mu.Unlock()
+	if !ok {
+		return Block{}, fmt.Errorf("block %d is not in the archive", index)
+	}
+
+	f, err := os.Open(loc.segment)
+	if err != nil {
+		return Block{}, err
+	}
+	defer f.Close()
+
+	record := make([]byte, loc.length)
+	if _, err := f.ReadAt(record, loc.offset); err != nil {
+		return Block{}, err
+	}
+	var block Block
+	if err := json.Unmarshal(record, &block); err != nil {
+		return Block{}, err
+	}
+	if TxRoot(block.Transactions) != block.TxRoot {
+		return Block{}, fmt.Errorf("archived block %d does not match its transaction root", index)
+	}
+	return block, nil
+}
+
+// EnablePruning keeps only the newest keepBlocks full blocks in memory and
+// moves older bodies to segment files under dir. Segments are kept per chain,
+// in a subdirectory named after the genesis block hash.
+func (bc *Blockchain) EnablePruning(dir string, keepBlocks int) error {
+	if keepBlocks <= 0 {
+		return errors.New("keepBlocks must be positive")
+	}
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	archive, err := openArchive(filepath.Join(dir, Hash(bc.Chain[0])[:16]))
+	if err != nil {
+		return err
+	}
+	bc.archive = archive
+	bc.keepBlocks = keepBlocks
+	err = bc.prune()
+	bc.publishView()
+	return err
+}
+
+// prune moves block bodies older than keepBlocks to the archive. The genesis
+// block is never pruned. Callers must hold bc.mutex.
+func (bc *Blockchain) prune() error {
+	if bc.archive == nil {
+		return nil
+	}
+	for i := 1; i < len(bc.Chain)-bc.keepBlocks; i++ {
+		block := &bc.Chain[i]
+		if block.Pruned {
+			continue
+		}
+		if err := bc.archive.append(*block); err != nil {
+			return err
+		}
+		block.Transactions = nil
+		block.Pruned = true
+		blocksPruned.Inc()
+	}
+	return nil
+}
+
+// indexBlock records which NFTs and transactions a block holds. Freezes are
+// indexed by hash only. Callers must hold bc.mutex.
+func (bc *Blockchain) indexBlock(block Block) {
+	for _, tx := range block.Transactions {
+		indexes := bc.nftIndex[tx.NFTId]
+		if tx.movesNFT() && (len(indexes) == 0 || indexes[len(indexes)-1] != block.Index) {
+			bc.nftIndex[tx.NFTId] = append(indexes, block.Index)
+		}
+		bc.txIndex[TxHash(tx)] = sealedTx{blockIndex: block.Index, nftId: tx.NFTId, txType: txType(tx)}
+	}
+}
+
+// BlockAt returns the full block with the given index, reading the body from
+// the archive if it has been pruned.
+func (bc *Blockchain) BlockAt(index int) (Block, error) {
+	bc.mutex.RLock()
+	if index < 1 || index > len(bc.Chain) {
+		bc.mutex.RUnlock()
+		return Block{}, fmt.Errorf("block %d does not exist", index)
+	}
+	block := bc.Chain[index-1]
+	archive := bc.archive
+	bc.mutex.RUnlock()
+
+	if !block.Pruned {
+		return block, nil
+	}
+	return archive.read(index)
+}
+
+// HistoryEntry is one transaction touching an NFT.
+type HistoryEntry struct {
+	BlockIndex  int         `json:"block_index,omitempty"` // 0 while pending
+	Timestamp   string      `json:"timestamp,omitempty"`
+	Transaction Transaction `json:"transaction"`
+}
+
+// NFTHistory returns every sealed and pending transaction for nftId, oldest
+// first. Sealed transactions in pruned blocks are read from the archive.
+// Freezes are left out; Compliance lists them.
+func (bc *Blockchain) NFTHistory(nftId string) ([]HistoryEntry, error) {
+	bc.mutex.RLock()
+	indexes := append([]int(nil), bc.nftIndex[nftId]...)
+	var pending []Transaction
+	for _, tx := range bc.CurrentTransactions {
+		if tx.NFTId == nftId && tx.movesNFT() {
+			pending = append(pending, tx)
+		}
+	}
+	bc.mutex.RUnlock()
+
+	var history []HistoryEntry
+	for _, index := range indexes {
+		block, err := bc.BlockAt(index)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range block.Transactions {
+			if tx.NFTId == nftId && tx.movesNFT() {
+				history = append(history, HistoryEntry{BlockIndex: block.Index, Timestamp: block.Timestamp, Transaction: tx})
+			}
+		}
+	}
+	for _, tx := range pending {
+		history = append(history, HistoryEntry{Transaction: tx})
+	}
+	return history, nil
+}
+
+// fullChain returns every block with its body, rehydrating pruned blocks.
+// Callers must hold bc.mutex.
+func (bc *Blockchain) fullChain() ([]Block, error) {
+	chain := append([]Block(nil), bc.Chain...)
+	for i, block := range chain {
+		if !block.Pruned {
+			continue
+		}
+		full, err := bc.archive.read(block.Index)
+		if err != nil {
+			return nil, err
+		}
+		chain[i] = full
+	}
+	return chain, nil
+}
+
+// NFTHistoryHandler returns the transactions that touched an NFT, including
+// those in blocks that have been moved to the archive.
+func NFTHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	type Request struct {
+		NFTId string `json:"nft_id"`
+	}
+
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	history, err := ledgerFor(r).NFTHistory(req.NFTId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Error("Failed to read NFT history", "nft_id", req.NFTId, "error", err)
+		return
+	}
+	if len(history) == 0 {
+		http.Error(w, "NFT not found", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"nft_id": req.NFTId, "history": history})
+}
+
+// BlockHandler returns a full block by index.
+func BlockHandler(w http.ResponseWriter, r *http.Request) {
+	type Request struct {
+		Index int `json:"index"`
+	}
+
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	block, err := ledgerFor(r).BlockAt(req.Index)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(block)
+}
@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Transaction envelopes
+//
+// Mints, transfers and burns can all be submitted as one signed envelope to
+// /tx/submit instead of through their own endpoints and signed messages.
+// The sender signs the SHA-256 of the envelope's canonical encoding (see
+// SigningBytes) with the wallet key, as for every other ledger signature.
+// Each sender's nonces must increase, so an envelope cannot be replayed;
+// gaps are allowed. /tx/simulate runs the same checks without applying
+// anything.
+
+// TxEnvelope is a signed transaction request.
+type TxEnvelope struct {
+	Type      string            `json:"type"` // "mint", "transfer" or "burn"
+	Sender    string            `json:"sender"`
+	NFTId     string            `json:"nft_id,omitempty"` // Empty for mints; the ID is derived from the envelope
+	Nonce     uint64            `json:"nonce"`            // Above the sender's last accepted nonce
+	Payload   map[string]string `json:"payload,omitempty"`
+	Signature string            `json:"signature"`
+	AuthBurn  *BurnProof        `json:"auth_burn,omitempty"` // For mints: proof of the burn named by the "auth_burn" payload key
+}
+
+// envelopePayload lists the payload keys each type accepts. Mints also take
+// "metadata.<key>" entries for the class metadata. The mint owner and the
+// transfer recipient default to the sender and the host wallet.
+var envelopePayload = map[string][]string{
+	"mint":     {"owner", "class", "username", "user_type", "token_type", "auth_burn"},
+	"transfer": {"recipient"},
+	"burn":     {},
+}
+
+// SigningBytes returns the canonical encoding of e for a ledger tenant
+// ("" for the default ledger): the compact JSON object
+//
+//	{"ledger":…,"tenant":…,"type":…,"sender":…,"nft_id":…,"nonce":…,"payload":{…}}
+//
+// with the members in that order, payload keys sorted, an empty payload
+// encoded as {} and <, > and & in strings escaped as \u003c, \u003e and
+// \u0026.
+func (e TxEnvelope) SigningBytes(tenant string) []byte {
+	return e.signingBytes(ledgerID, tenant)
+}
+
+// signingBytes is SigningBytes for the named ledger.
+func (e TxEnvelope) signingBytes(ledger, tenant string) []byte {
+	payload := e.Payload
+	if payload == nil {
+		payload = map[string]string{}
+	}
+	b, _ := json.Marshal(struct {
+		Ledger  string            `json:"ledger"`
+		Tenant  string            `json:"tenant"`
+		Type    string            `json:"type"`
+		Sender  string            `json:"sender"`
+		NFTId   string            `json:"nft_id"`
+		Nonce   uint64            `json:"nonce"`
+		Payload map[string]string `json:"payload"`
+	}{ledger, tenant, e.Type, e.Sender, e.NFTId, e.Nonce, payload})
+	return b
+}
+
+// envelopeOp is a decoded envelope: the transaction it would add and the
+// message its signature covers.
+type envelopeOp struct {
+	tx       Transaction
+	message  string
+	details  *TokenDetails
+	class    string
+	metadata map[string]string
+}
+
+// decodeEnvelope checks the shape of e and builds its transaction.
+func (bc *Blockchain) decodeEnvelope(e TxEnvelope) (*envelopeOp, error) {
+	allowed, ok := envelopePayload[e.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction type %q", e.Type)
+	}
+	if err := requireFields("sender", e.Sender, "signature", e.Signature); err != nil {
+		return nil, err
+	}
+	if e.Nonce == 0 {
+		return nil, errors.New("nonce must be at least 1")
+	}
+	for key := range e.Payload {
+		if !slices.Contains(allowed, key) && !(e.Type == "mint" && strings.HasPrefix(key, "metadata.")) {
+			return nil, fmt.Errorf("unknown payload key %q for %s", key, e.Type)
+		}
+	}
+
+	if e.AuthBurn != nil && e.Type != "mint" {
+		return nil, errors.New("only mint envelopes carry an auth_burn proof")
+	}
+	if (e.AuthBurn == nil && e.Payload["auth_burn"] != "") || (e.AuthBurn != nil && e.AuthBurn.TxHash != e.Payload["auth_burn"]) {
+		return nil, errors.New("auth_burn proof does not match the signed payload")
+	}
+
+	op := &envelopeOp{message: string(e.SigningBytes(bc.tenant))}
+	tx := Transaction{Sender: e.Sender, NFTId: e.NFTId, Signature: e.Signature, Nonce: e.Nonce}
+	switch e.Type {
+	case "mint":
+		if e.NFTId != "" {
+			return nil, errors.New("mint envelopes must not name an NFT")
+		}
+		hash := sha256.Sum256([]byte(op.message))
+		tx.NFTId = "nft-" + hex.EncodeToString(hash[:16])
+		tx.Recipient = e.Payload["owner"]
+		if tx.Recipient == "" {
+			tx.Recipient = e.Sender
+		}
+		tx.AuthBurn = e.AuthBurn
+		op.class = e.Payload["class"]
+		username, userType, tokenType := e.Payload["username"], e.Payload["user_type"], e.Payload["token_type"]
+		if username != "" || userType != "" || tokenType != "" {
+			op.details = &TokenDetails{Username: username, UserType: userType, TokenType: tokenType}
+		}
+		for key, value := range e.Payload {
+			if name, ok := strings.CutPrefix(key, "metadata."); ok {
+				if op.metadata == nil {
+					op.metadata = make(map[string]string)
+				}
+				op.metadata[name] = value
+			}
+		}
+	case "transfer":
+		if err := requireFields("nft_id", e.NFTId); err != nil {
+			return nil, err
+		}
+		tx.Type = "transfer"
+		tx.Recipient = e.Payload["recipient"]
+		if tx.Recipient == "" {
+			tx.Recipient = bc.HostWallet
+		}
+	case "burn":
+		if err := requireFields("nft_id", e.NFTId); err != nil {
+			return nil, err
+		}
+		tx.Type = "burn"
+		tx.Recipient = bc.HostWallet
+	}
+	op.tx = tx
+	return op, nil
+}
+
+// checkNonce rejects an envelope transaction whose nonce is not above the
+// sender's last accepted one. Transactions from the older endpoints carry
+// no nonce. Callers must hold bc.mutex.
+func (bc *Blockchain) checkNonce(tx Transaction) error {
+	if tx.Nonce == 0 {
+		return nil
+	}
+	if last := bc.nonces[tx.Sender]; tx.Nonce <= last {
+		return &APIError{http.StatusConflict, codeBadNonce, fmt.Sprintf("nonce must be above %d", last)}
+	}
+	return nil
+}
+
+// SubmitEnvelope checks e and applies it as the matching endpoint would. A
+// rejected envelope returns its receipt along with the error.
+func (bc *Blockchain) SubmitEnvelope(e TxEnvelope) (Receipt, error) {
+	op, err := bc.decodeEnvelope(e)
+	if err != nil {
+		return Receipt{}, err
+	}
+	switch op.tx.Type {
+	case "transfer":
+		return bc.transferNFT(op.tx, op.message)
+	case "burn":
+		return bc.burnNFT(op.tx, op.message)
+	default:
+		return bc.createNFT(op.tx, op.message, op.details, op.class, op.metadata)
+	}
+}
+
+// Simulation is what submitting an envelope now would do.
+type Simulation struct {
+	Applies bool       `json:"applies"`
+	Receipt Receipt    `json:"receipt"`          // Pending if it applies, otherwise rejected with the reason
+	Owner   string     `json:"owner,omitempty"`  // Owner of the NFT afterwards, if it applies
+	Burned  bool       `json:"burned,omitempty"` // Whether the NFT would be burned
+	Error   *errorBody `json:"error,omitempty"`  // The error submitting would return
+}
+
+// reject turns s into the simulation of a rejection with err.
+func (s Simulation) reject(err error) Simulation {
+	_, body := errorBodyFor(err)
+	s.Applies, s.Owner, s.Burned, s.Error = false, "", false, &body
+	s.Receipt.Status, s.Receipt.Reason = "rejected", err.Error()
+	return s
+}
+
+// SimulateEnvelope runs every check SubmitEnvelope would without applying
+// e. Policy violations it finds are not counted or published.
+func (bc *Blockchain) SimulateEnvelope(e TxEnvelope) (Simulation, error) {
+	op, err := bc.decodeEnvelope(e)
+	if err != nil {
+		return Simulation{}, err
+	}
+	tx := op.tx
+	sim := Simulation{
+		Applies: true,
+		Receipt: Receipt{TxHash: TxHash(tx), Type: txType(tx), NFTId: tx.NFTId, Status: "pending"},
+		Owner:   tx.Recipient,
+		Burned:  tx.Type == "burn",
+	}
+
+	now := time.Now()
+	if tx.Type == "" {
+		tokenClass, err := lookupClass(op.class)
+		if err != nil {
+			return sim.reject(err), nil
+		}
+		if _, err := newClassInfo(tokenClass, op.metadata, now); err != nil {
+			return sim.reject(err), nil
+		}
+	}
+
+	bc.mutex.RLock()
+	switch tx.Type {
+	case "transfer":
+		err = bc.checkTransfer(tx, op.message, now, true)
+	case "burn":
+		err = bc.checkBurn(tx, op.message, now, true)
+	default:
+		var details *TokenDetails
+		if details, err = bc.mintDetails(tx.Recipient, op.details); err == nil {
+			err = bc.checkMint(tx, op.message, details, now, true)
+		}
+	}
+	bc.mutex.RUnlock()
+	if err != nil {
+		return sim.reject(err), nil
+	}
+	return sim, nil
+}
+
+// SubmitTxHandler applies a signed TxEnvelope. Errors use the /v1 envelope.
+func SubmitTxHandler(w http.ResponseWriter, r *http.Request) {
+	var e TxEnvelope
+	if err := decodeV1(r, &e); err != nil {
+		writeError(w, err)
+		return
+	}
+	if e.Type == "mint" {
+		if err := checkMintAllowed(r, e.Payload["class"]); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	receipt, err := ledgerFor(r).SubmitEnvelope(e)
+	if err != nil {
+		log.Error("Transaction rejected", "type", e.Type, "sender", e.Sender, "nonce", e.Nonce, "error", err)
+		writeError(w, err)
+		return
+	}
+	status := http.StatusOK
+	if e.Type == "mint" {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, map[string]Receipt{"receipt": receipt})
+}
+
+// SimulateTxHandler reports what submitting a TxEnvelope would do. A
+// transaction that would be rejected is still a 200 response; only
+// malformed envelopes are errors.
+func SimulateTxHandler(w http.ResponseWriter, r *http.Request) {
+	var e TxEnvelope
+	if err := decodeV1(r, &e); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sim, err := ledgerFor(r).SimulateEnvelope(e)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if sim.Applies && e.Type == "mint" {
+		if err := checkMintAllowed(r, e.Payload["class"]); err != nil {
+			sim = sim.reject(err)
+		}
+	}
+	writeJSON(w, http.StatusOK, sim)
+}
+
+// NonceHandler returns the last nonce accepted from sender: GET
+// ?sender=. The next envelope must use a higher one.
+func NonceHandler(w http.ResponseWriter, r *http.Request) {
+	sender := r.URL.Query().Get("sender")
+	if err := requireFields("sender", sender); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	bc := ledgerFor(r)
+	bc.mutex.RLock()
+	nonce := bc.nonces[sender]
+	bc.mutex.RUnlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sender": sender, "nonce": nonce})
+}
+
+// RegisterTx adds the envelope routes using handle, as RegisterV1 does.
+func RegisterTx(handle func(pattern string, h http.HandlerFunc)) {
+	handle("/tx/submit", allowMethod(http.MethodPost, SubmitTxHandler))
+	handle("/tx/simulate", allowMethod(http.MethodPost, SimulateTxHandler))
+	handle("/tx/nonce", allowMethod(http.MethodGet, NonceHandler))
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// eventBufferSize is the number of recent events kept for /events.
+const eventBufferSize = 1000
+
+// Event is a ledger occurrence published to operators and subscribers.
+type Event struct {
+	Seq    int64             `json:"seq"`
+	Type   string            `json:"type"`
+	Time   time.Time         `json:"time"`
+	NFTId  string            `json:"nft_id,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// eventLog is an in-memory ring of recent events. Sequence numbers keep
+// increasing so consumers can poll with the last one they saw. Each ledger
+// has its own log, so tenants only see their own events.
+type eventLog struct {
+	mu     sync.Mutex
+	seq    int64
+	events []Event
+}
+
+// emitEvent records an event and logs it.
+func (bc *Blockchain) emitEvent(eventType, nftId string, fields map[string]string) Event {
+	events := bc.events
+	events.mu.Lock()
+	events.seq++
+	event := Event{Seq: events.seq, Type: eventType, Time: time.Now().UTC(), NFTId: nftId, Fields: fields}
+	events.events = append(events.events, event)
+	if len(events.events) > eventBufferSize {
+		events.events = events.events[len(events.events)-eventBufferSize:]
+	}
+	events.mu.Unlock()
+
+	eventsEmitted.Inc(eventType)
+	log.Info("Event emitted", "tenant", bc.tenant, "type", eventType, "seq", event.Seq, "nft_id", nftId, "fields", fields)
+	return event
+}
+
+// eventsSince returns buffered events with a sequence number above since.
+func (bc *Blockchain) eventsSince(since int64) []Event {
+	events := bc.events
+	events.mu.Lock()
+	defer events.mu.Unlock()
+
+	result := []Event{}
+	for _, event := range events.events {
+		if event.Seq > since {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+// EventsHandler lists recent events after the optional since query parameter.
+func EventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var since int64
+	if value := r.URL.Query().Get("since"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid since", http.StatusBadRequest)
+			return
+		}
+		since = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ledgerFor(r).eventsSince(since))
+}
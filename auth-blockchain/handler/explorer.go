@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// BlockSummary is a block header as listed by the explorer.
+type BlockSummary struct {
+	Index        int    `json:"index"`
+	Timestamp    string `json:"timestamp"`
+	Hash         string `json:"hash"`
+	PreviousHash string `json:"previous_hash"`
+	TxCount      int    `json:"tx_count"`
+	Pruned       bool   `json:"pruned"`
+}
+
+// RecentBlocks returns summaries of the newest limit blocks, newest first.
+// It reads the chain view and takes no lock.
+func (bc *Blockchain) RecentBlocks(limit int) []BlockSummary {
+	blocks := bc.view.Load().blocks
+	summaries := make([]BlockSummary, 0, limit)
+	for i := len(blocks) - 1; i >= 0 && len(summaries) < limit; i-- {
+		summaries = append(summaries, blocks[i])
+	}
+	return summaries
+}
+
+// Mempool returns a copy of the transactions waiting to be mined.
+func (bc *Blockchain) Mempool() []Transaction {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	return append([]Transaction{}, bc.CurrentTransactions...)
+}
+
+// WalletNFTs returns the IDs of the NFTs owned by address, sorted, and
+// whether the address is a registered wallet.
+func (bc *Blockchain) WalletNFTs(address string) ([]string, bool) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	nfts := []string{}
+	for id, owner := range bc.NFTs {
+		if owner == address {
+			nfts = append(nfts, id)
+		}
+	}
+	sort.Strings(nfts)
+	_, registered := bc.wallet(address)
+	return nfts, registered
+}
+
+// RecentBlocksHandler lists the newest blocks. The limit query parameter
+// defaults to 20 and is capped at 200.
+func RecentBlocksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 20
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ledgerFor(r).RecentBlocks(limit))
+}
+
+// MempoolHandler lists the transactions waiting to be mined.
+func MempoolHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ledgerFor(r).Mempool())
+}
+
+// WalletNFTsHandler lists the NFTs held by the wallet in the address query
+// parameter.
+func WalletNFTsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		http.Error(w, "Missing address", http.StatusBadRequest)
+		return
+	}
+
+	nfts, registered := ledgerFor(r).WalletNFTs(address)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"address":    address,
+		"registered": registered,
+		"nfts":       nfts,
+	})
+}
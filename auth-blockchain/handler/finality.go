@@ -0,0 +1,38 @@
+package handler
+
+import "fmt"
+
+// Finality says how settled an NFT's current ownership is. Ownership is
+// pending while a transaction touching the NFT waits in the mempool, and
+// sealed once the latest such transaction is in a block.
+type Finality struct {
+	Status        string `json:"status"`                // "pending" or "sealed"
+	BlockIndex    int    `json:"block_index,omitempty"` // Block holding the latest transaction; 0 while pending
+	Confirmations int    `json:"confirmations"`         // Blocks from BlockIndex to the tip, inclusive; 0 while pending
+}
+
+// finality returns the finality of nftId's current ownership. Callers must
+// hold bc.mutex.
+func (bc *Blockchain) finality(nftId string) Finality {
+	for _, tx := range bc.CurrentTransactions {
+		if tx.NFTId == nftId && tx.movesNFT() {
+			return Finality{Status: "pending"}
+		}
+	}
+	indexes := bc.nftIndex[nftId]
+	if len(indexes) == 0 {
+		return Finality{Status: "pending"}
+	}
+	index := indexes[len(indexes)-1]
+	return Finality{Status: "sealed", BlockIndex: index, Confirmations: len(bc.Chain) - index + 1}
+}
+
+// confirmed reports whether f meets minConfirmations, returning an error
+// describing the shortfall if not.
+func (f Finality) confirmed(minConfirmations int) error {
+	if f.Confirmations >= minConfirmations {
+		return nil
+	}
+	return &APIError{ErrUnconfirmed.Status, ErrUnconfirmed.Code,
+		fmt.Sprintf("NFT has %d confirmations, %d required", f.Confirmations, minConfirmations)}
+}
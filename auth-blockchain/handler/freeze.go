@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Freezes
+//
+// An operator can freeze an NFT or a wallet during an investigation. While
+// frozen, an NFT cannot be transferred, burned or validated, and neither can
+// any NFT held by a frozen wallet; the reaper leaves frozen NFTs alone so no
+// evidence is destroyed. Every freeze and unfreeze is a host-signed
+// transaction carrying a FreezeRecord, so the chain holds the compliance
+// history. These transactions do not move ownership and are skipped by the
+// state tree, finality and NFT history.
+
+// Freeze target kinds.
+const (
+	FreezeNFT    = "nft"
+	FreezeWallet = "wallet"
+)
+
+// freezeReasons are the reason codes a freeze or unfreeze may give.
+var freezeReasons = []string{"investigation", "compromised", "fraud", "legal_hold", "resolved", "other"}
+
+// FreezeRecord is the on-chain record of a freeze or unfreeze.
+type FreezeRecord struct {
+	Kind   string `json:"kind"`   // FreezeNFT or FreezeWallet
+	Target string `json:"target"` // NFT ID or wallet address
+	Reason string `json:"reason"` // One of freezeReasons
+	Note   string `json:"note,omitempty"`
+	Actor  string `json:"actor"` // Who ordered it, as given by the operator
+	Time   int64  `json:"time"`  // Unix seconds
+}
+
+// movesNFT reports whether tx changes NFT ownership. Freeze and unfreeze
+// transactions only record compliance actions, and anchor transactions the
+// peer ledger's tip.
+func (tx Transaction) movesNFT() bool {
+	return tx.Freeze == nil && tx.Anchor == nil
+}
+
+// frozen returns the error for the first of nftId and addresses that is
+// frozen, if any. Empty arguments are ignored. Callers must hold bc.mutex.
+func (bc *Blockchain) frozen(nftId string, addresses ...string) error {
+	if _, ok := bc.frozenNFTs[nftId]; ok && nftId != "" {
+		return ErrNFTFrozen
+	}
+	for _, address := range addresses {
+		if _, ok := bc.frozenWallets[address]; ok && address != "" {
+			return ErrWalletFrozen
+		}
+	}
+	return nil
+}
+
+// applyFreeze updates the frozen sets for a freeze or unfreeze transaction
+// and logs it. Callers must hold bc.mutex for writing.
+func (bc *Blockchain) applyFreeze(tx Transaction) {
+	record := *tx.Freeze
+	set := bc.frozenNFTs
+	if record.Kind == FreezeWallet {
+		set = bc.frozenWallets
+	}
+	if tx.Type == "freeze" {
+		set[record.Target] = record
+	} else {
+		delete(set, record.Target)
+	}
+	bc.freezeLog = append(bc.freezeLog, tx)
+}
+
+// SetFrozen freezes or unfreezes record.Target and records it on-chain.
+func (bc *Blockchain) SetFrozen(record FreezeRecord, freeze bool, now time.Time) (Receipt, error) {
+	if record.Kind != FreezeNFT && record.Kind != FreezeWallet {
+		return Receipt{}, fmt.Errorf("kind must be %s or %s", FreezeNFT, FreezeWallet)
+	}
+	if err := requireFields("target", record.Target, "actor", record.Actor); err != nil {
+		return Receipt{}, err
+	}
+	if !slices.Contains(freezeReasons, record.Reason) {
+		return Receipt{}, fmt.Errorf("reason must be one of %v", freezeReasons)
+	}
+	record.Time = now.Unix()
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	set := bc.frozenNFTs
+	tx := Transaction{Sender: bc.HostWallet, Recipient: bc.HostWallet, Type: "freeze", Signer: bc.HostWallet, Freeze: &record}
+	if record.Kind == FreezeNFT {
+		if _, exists := bc.NFTs[record.Target]; !exists {
+			return Receipt{}, ErrNFTNotFound
+		}
+		tx.NFTId = record.Target
+	} else {
+		if record.Target == bc.HostWallet {
+			return Receipt{}, errors.New("the host wallet cannot be frozen")
+		}
+		if _, registered := bc.wallet(record.Target); !registered {
+			return Receipt{}, errors.New("wallet is not registered")
+		}
+		set = bc.frozenWallets
+	}
+	_, isFrozen := set[record.Target]
+	switch {
+	case freeze && isFrozen:
+		return Receipt{}, fmt.Errorf("%s is already frozen", record.Kind)
+	case !freeze && !isFrozen:
+		return Receipt{}, fmt.Errorf("%s is not frozen", record.Kind)
+	case !freeze:
+		tx.Type = "unfreeze"
+	}
+
+	recordBytes, _ := json.Marshal(record)
+	signature, err := bc.hostSign(append([]byte(tx.Type+":"), recordBytes...))
+	if err != nil {
+		return Receipt{}, err
+	}
+	tx.Signature = signature
+
+	bc.applyFreeze(tx)
+	receipt := bc.addTransaction(tx)
+	bc.emitEvent(record.Kind+"."+tx.Type, tx.NFTId, map[string]string{
+		"target": record.Target, "reason": record.Reason, "actor": record.Actor, "tx_hash": receipt.TxHash,
+	})
+	log.Warn("Freeze state changed", "action", tx.Type, "kind", record.Kind, "target", record.Target,
+		"reason", record.Reason, "actor", record.Actor)
+	return receipt, nil
+}
+
+// ComplianceEntry is one freeze or unfreeze in the compliance listing.
+type ComplianceEntry struct {
+	Action  string       `json:"action"` // "freeze" or "unfreeze"
+	Record  FreezeRecord `json:"record"`
+	Receipt Receipt      `json:"receipt"`
+}
+
+// Compliance returns the active freezes and every freeze and unfreeze,
+// oldest first, optionally only those for target.
+func (bc *Blockchain) Compliance(target string) (active []FreezeRecord, history []ComplianceEntry) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	active, history = []FreezeRecord{}, []ComplianceEntry{}
+	for _, set := range []map[string]FreezeRecord{bc.frozenNFTs, bc.frozenWallets} {
+		for _, record := range set {
+			if target == "" || record.Target == target {
+				active = append(active, record)
+			}
+		}
+	}
+	slices.SortFunc(active, func(a, b FreezeRecord) int { return int(a.Time - b.Time) })
+
+	pending := make(map[string]bool, len(bc.CurrentTransactions))
+	for _, tx := range bc.CurrentTransactions {
+		if tx.Freeze != nil {
+			pending[TxHash(tx)] = true
+		}
+	}
+	for _, tx := range bc.freezeLog {
+		if target != "" && tx.Freeze.Target != target {
+			continue
+		}
+		hash := TxHash(tx)
+		receipt := Receipt{TxHash: hash, Type: tx.Type, NFTId: tx.NFTId, Status: "pending"}
+		if sealed, ok := bc.txIndex[hash]; ok && !pending[hash] {
+			receipt.Status = "sealed"
+			receipt.BlockIndex = sealed.blockIndex
+			receipt.Confirmations = len(bc.Chain) - sealed.blockIndex + 1
+		}
+		history = append(history, ComplianceEntry{Action: tx.Type, Record: *tx.Freeze, Receipt: receipt})
+	}
+	return active, history
+}
+
+// freezeHandler serves /admin/freeze and /admin/unfreeze: POST {kind,
+// target, reason, note?, actor}.
+func freezeHandler(freeze bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var record FreezeRecord
+		if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			log.Error("Failed to decode request body", err)
+			return
+		}
+
+		receipt, err := ledgerFor(r).SetFrozen(record, freeze, time.Now())
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, ErrNFTNotFound) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			log.Error("Failed to change freeze state", "kind", record.Kind, "target", record.Target, "error", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]Receipt{"receipt": receipt})
+	}
+}
+
+// FreezeHandler freezes an NFT or wallet.
+var FreezeHandler = freezeHandler(true)
+
+// UnfreezeHandler lifts a freeze.
+var UnfreezeHandler = freezeHandler(false)
+
+// ComplianceHandler lists active freezes and the freeze history, optionally
+// for one target: GET ?target=.
+func ComplianceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	active, history := ledgerFor(r).Compliance(r.URL.Query().Get("target"))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"active": active, "history": history})
+}
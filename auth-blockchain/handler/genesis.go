@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Genesis
+//
+// Without configuration every node mines its own genesis block at startup,
+// so no two nodes share a chain. A genesis file fixes the genesis block: its
+// timestamp and proof come from the file and its previous hash is the hash
+// of the whole configuration, so the genesis hash commits to every field.
+// Tenant ledgers get the same configuration under their tenant ID. A node
+// only imports snapshots whose chain starts with its own genesis block.
+
+// GenesisConfig is the content of a genesis file.
+type GenesisConfig struct {
+	ChainID     string          `json:"chain_id"`
+	Ledger      string          `json:"ledger"`                 // "auth" or "req"; must match the node
+	Timestamp   string          `json:"timestamp"`              // RFC 3339
+	HostWallets []string        `json:"host_wallets,omitempty"` // Host wallets the default ledger may run with; empty allows any
+	Wallets     []string        `json:"wallets,omitempty"`      // Registered in the genesis state
+	Consensus   ConsensusParams `json:"consensus"`
+	Classes     []*TokenClass   `json:"classes,omitempty"` // Replaces TOKEN_CLASSES_FILE
+}
+
+// ConsensusParams are the proof-of-work parameters.
+type ConsensusParams struct {
+	Difficulty   int `json:"difficulty"`    // Leading zero hex digits of a valid proof; default 4
+	GenesisProof int `json:"genesis_proof"` // Proof recorded in the genesis block; default 100
+}
+
+// genesis is the loaded genesis configuration, or nil.
+var genesis *GenesisConfig
+
+// powDifficulty is the number of leading zero hex digits ValidProof wants.
+var powDifficulty = 4
+
+// hash returns the hex SHA-256 of the configuration for a tenant ledger
+// ("" for the default ledger).
+func (g *GenesisConfig) hash(tenant string) string {
+	b, _ := json.Marshal(struct {
+		Tenant string `json:"tenant"`
+		*GenesisConfig
+	}{tenant, g})
+	hash := sha256.Sum256(b)
+	return hex.EncodeToString(hash[:])
+}
+
+// registerGenesisWallets registers the host and user wallets of the genesis
+// configuration and returns them sorted, recorded as added by the genesis
+// block. Callers must hold bc.mutex for writing.
+func (bc *Blockchain) registerGenesisWallets() []string {
+	var wallets []string
+	for _, address := range append(slices.Clone(genesis.HostWallets), genesis.Wallets...) {
+		if _, seen := bc.walletHeights[address]; seen {
+			continue
+		}
+		pub, _ := publicKeyFromAddress(address)
+		bc.addWallet(address, pub)
+		bc.walletHeights[address] = 1
+		wallets = append(wallets, address)
+	}
+	sort.Strings(wallets)
+	return wallets
+}
+
+// LoadGenesis reads the genesis file at path, rebuilds the default ledger's
+// genesis block from it and returns the block's hash. It must run after
+// InitHostWallet and before tenants are added or the ledger is used.
+func LoadGenesis(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read genesis file: %w", err)
+	}
+	var config GenesisConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", fmt.Errorf("failed to parse genesis file: %w", err)
+	}
+
+	if config.ChainID == "" {
+		return "", errors.New("genesis has no chain_id")
+	}
+	if config.Ledger != ledgerID {
+		return "", fmt.Errorf("genesis is for ledger %q, this node is %q", config.Ledger, ledgerID)
+	}
+	timestamp, err := time.Parse(time.RFC3339, config.Timestamp)
+	if err != nil {
+		return "", fmt.Errorf("invalid genesis timestamp: %w", err)
+	}
+	config.Timestamp = timestamp.UTC().Format(time.RFC3339)
+	for _, address := range append(slices.Clone(config.HostWallets), config.Wallets...) {
+		if _, err := publicKeyFromAddress(address); err != nil {
+			return "", fmt.Errorf("genesis wallet %s: %w", address, err)
+		}
+	}
+	if config.Consensus.Difficulty == 0 {
+		config.Consensus.Difficulty = 4
+	}
+	if config.Consensus.Difficulty < 1 || config.Consensus.Difficulty > 8 {
+		return "", fmt.Errorf("consensus difficulty must be between 1 and 8, not %d", config.Consensus.Difficulty)
+	}
+	if config.Consensus.GenesisProof == 0 {
+		config.Consensus.GenesisProof = 100
+	}
+	if config.Classes != nil {
+		if err := setTokenClasses(config.Classes); err != nil {
+			return "", err
+		}
+	}
+
+	bc := blockchain
+	bc.mutex.Lock()
+	if len(config.HostWallets) > 0 && !slices.Contains(config.HostWallets, bc.HostWallet) {
+		bc.mutex.Unlock()
+		return "", fmt.Errorf("host wallet %s is not a genesis host wallet", bc.HostWallet)
+	}
+	if len(bc.Chain) > 1 || len(bc.CurrentTransactions) > 0 {
+		bc.mutex.Unlock()
+		return "", errors.New("genesis can only be loaded into a fresh node")
+	}
+	bc.Chain = nil
+	bc.states = nil
+	bc.walletHeights = make(map[string]int)
+	genesis = &config
+	powDifficulty = config.Consensus.Difficulty
+	bc.mutex.Unlock()
+
+	bc.CreateGenesisBlock()
+	return bc.GenesisHash(), nil
+}
+
+// GenesisHash returns the hash of bc's genesis block.
+func (bc *Blockchain) GenesisHash() string {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	return Hash(bc.Chain[0])
+}
+
+// GenesisHandler describes the ledger's genesis block, so operators and
+// nodes can check that they share a chain before exchanging data.
+func GenesisHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	bc := ledgerFor(r)
+	response := map[string]interface{}{
+		"ledger":       ledgerID,
+		"tenant":       bc.tenant,
+		"genesis_hash": bc.GenesisHash(),
+		"configured":   genesis != nil,
+	}
+	if genesis != nil {
+		response["chain_id"] = genesis.ChainID
+		response["config"] = genesis
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -12,8 +12,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,6 +25,35 @@ type Block struct {
 	Transactions []Transaction
 	Proof        int
 	PreviousHash string
+	TxRoot       string // Hash of Transactions, kept when the body is pruned
+	TxCount      int
+	StateRoot    string `json:",omitempty"` // Root of the state tree after this block; see stateroot.go
+	Pruned       bool   `json:",omitempty"` // Body moved to the archive
+}
+
+// BlockHeader is the hashed part of a block. It stays in memory after the
+// block body has been pruned to the archive.
+type BlockHeader struct {
+	Index        int
+	Timestamp    string
+	Proof        int
+	PreviousHash string
+	TxRoot       string
+	TxCount      int
+	StateRoot    string `json:",omitempty"`
+}
+
+// Header returns the block's header.
+func (b Block) Header() BlockHeader {
+	return BlockHeader{
+		Index:        b.Index,
+		Timestamp:    b.Timestamp,
+		Proof:        b.Proof,
+		PreviousHash: b.PreviousHash,
+		TxRoot:       b.TxRoot,
+		TxCount:      b.TxCount,
+		StateRoot:    b.StateRoot,
+	}
 }
 
 // Transaction structure for NFT operations
@@ -33,6 +63,11 @@ type Transaction struct {
 	NFTId     string
 	Signature string
 	Type      string `json:"type,omitempty"` // "transfer" or "burn"
+	Signer    string `json:"signer,omitempty"` // Set when the host signs on the owner's behalf
+	Nonce     uint64 `json:"nonce,omitempty"`  // Set for envelope transactions; see envelope.go
+	Freeze    *FreezeRecord `json:"freeze,omitempty"` // Set for freeze and unfreeze transactions; see freeze.go
+	AuthBurn  *BurnProof    `json:"auth_burn,omitempty"` // Set on mints authorized by an AuthNFT burn; see authlink.go
+	Anchor    *Anchor       `json:"anchor,omitempty"`    // Set for peer ledger anchor transactions; see anchor.go
 }
 
 // NFT structure
@@ -43,16 +78,72 @@ type NFT struct {
 
 // Blockchain structure
 type Blockchain struct {
-	mutex               sync.Mutex
+	mutex               sync.RWMutex // See state.go for the locking model
 	NFTs                map[string]string // NFT ID to Owner
 	CurrentTransactions []Transaction
 	HostWallet          string // Address of the host wallet
-	Wallets             map[string]*ecdsa.PublicKey
+	Wallets             map[string]*ecdsa.PublicKey // Guarded by walletMutex
 	Chain               []Block
+
+	walletMutex sync.RWMutex
+	mineMutex   sync.Mutex
+	view        atomic.Pointer[chainView] // Chain summary for lock-free readers
+
+	archive    *blockArchive    // Cold storage for pruned block bodies
+	keepBlocks int              // Full blocks kept in memory; 0 disables pruning
+	nftIndex   map[string][]int // NFT ID to indexes of blocks that touch it
+	txIndex    map[string]sealedTx // Transaction hash to the block that sealed it
+	rejected   *rejectedLog        // Recently rejected transactions; see receipts.go
+
+	states        []*smtNode     // State tree after each block, by block index - 1
+	walletHeights map[string]int // Wallet address to the block whose state added it
+
+	activity  map[string]*nftActivity // NFT ID to mint and last-use times
+	approvals map[string]*Approval    // Approval ID to operator approval
+	proposals map[string]*Proposal    // Proposal ID to multisig admin proposal
+
+	users  map[string]UserInfo      // Wallet address to registered user
+	tokens map[string]*TokenDetails // NFT ID to details bound at mint
+
+	burned map[string]bool // Burned NFT IDs; their owner is the host wallet
+	mints  map[string][]time.Time // Owner to recent mint times, for mint_rate rules
+
+	classes map[string]*NFTClassInfo // NFT ID to class record; absent for the default class
+
+	masters  map[string]string    // Master wallet address to hex chain code
+	children map[string]ChildLink // Child address to its master and path
+
+	nonces map[string]uint64 // Sender address to the highest envelope nonce accepted
+
+	frozenNFTs    map[string]FreezeRecord // NFT ID to its active freeze
+	frozenWallets map[string]FreezeRecord // Wallet address to its active freeze
+	freezeLog     []Transaction           // Freeze and unfreeze transactions, oldest first
+
+	authBurns map[string]string     // AuthNFT burn hash to the NFT it authorized
+	origins   map[string]*BurnProof // NFT ID to the burn proof it was minted with
+
+	anchors  []Transaction // Peer ledger anchor transactions, oldest first
+	anchored int           // Tip index of the last anchor the peer accepted from this ledger
+
+	tenant     string            // Namespace ID; empty for the default ledger
+	hostKey    *ecdsa.PrivateKey // Signs host-side artifacts such as snapshots
+	maxNFTs    int               // Live NFT quota; 0 is unlimited
+	maxWallets int               // Wallet quota, excluding the host wallet; 0 is unlimited
+	events     *eventLog
+	challenges *challengeStore
 }
 
+// blockchain is the default ledger, served when a request names no tenant.
 var blockchain *Blockchain
 
+// log is the service logger. main injects it with SetLogger before serving.
+var log = logger.NewNop()
+
+// SetLogger sets the logger used by the handler package.
+func SetLogger(l logger.Logger) {
+	log = l
+}
+
 func init() {
 	blockchain = NewBlockchain()
 	blockchain.CreateGenesisBlock()
@@ -65,10 +156,32 @@ func NewBlockchain() *Blockchain {
 		CurrentTransactions: []Transaction{},
 		NFTs:                make(map[string]string),
 		Wallets:             make(map[string]*ecdsa.PublicKey),
+		nftIndex:            make(map[string][]int),
+		txIndex:             make(map[string]sealedTx),
+		rejected:            &rejectedLog{receipts: make(map[string]Receipt)},
+		walletHeights:       make(map[string]int),
+		activity:            make(map[string]*nftActivity),
+		approvals:           make(map[string]*Approval),
+		proposals:           make(map[string]*Proposal),
+		users:               make(map[string]UserInfo),
+		tokens:              make(map[string]*TokenDetails),
+		burned:              make(map[string]bool),
+		mints:               make(map[string][]time.Time),
+		classes:             make(map[string]*NFTClassInfo),
+		masters:             make(map[string]string),
+		children:            make(map[string]ChildLink),
+		nonces:              make(map[string]uint64),
+		frozenNFTs:          make(map[string]FreezeRecord),
+		frozenWallets:       make(map[string]FreezeRecord),
+		authBurns:           make(map[string]string),
+		origins:             make(map[string]*BurnProof),
+		events:              &eventLog{},
+		challenges:          &challengeStore{byNonce: make(map[string]challenge)},
 	}
 }
 
-// Create genesis block
+// Create genesis block. With a genesis file loaded the block is the same on
+// every node; see genesis.go.
 func (bc *Blockchain) CreateGenesisBlock() {
 	bc.mutex.Lock()
 	defer bc.mutex.Unlock()
@@ -79,143 +192,263 @@ func (bc *Blockchain) CreateGenesisBlock() {
 		Transactions: []Transaction{},
 		Proof:        100,
 		PreviousHash: "1",
+		TxRoot:       TxRoot(nil),
+	}
+	var state *smtNode
+	if genesis != nil {
+		state = nextState(nil, nil, bc.registerGenesisWallets())
+		genesisBlock.Timestamp = genesis.Timestamp
+		genesisBlock.Proof = genesis.Consensus.GenesisProof
+		genesisBlock.PreviousHash = genesis.hash(bc.tenant)
+	} else {
+		state = nextState(nil, nil, bc.newStateWallets(1))
 	}
+	genesisBlock.StateRoot = stateRoot(state)
 
 	bc.Chain = append(bc.Chain, genesisBlock)
+	bc.states = append(bc.states, state)
+	bc.publishView()
 }
 
-// Create new NFT
-func (bc *Blockchain) CreateNFT(owner string, nftId string) error {
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
+// Create new NFT. When details is non-nil the owner must be a registered
+// user and the details are bound to the NFT, as mintToken does in
+// AuthToken.sol. The auth ledger fills nil details from the owner's
+// registration. An empty class mints in the ledger's default class;
+// metadata keys must be allowed by the class. A rejected mint returns its
+// receipt along with the error.
+func (bc *Blockchain) CreateNFT(owner string, nftId string, authBurn *BurnProof, details *TokenDetails, class string, metadata map[string]string) (Receipt, error) {
+	return bc.createNFT(Transaction{Recipient: owner, NFTId: nftId, AuthBurn: authBurn}, "", details, class, metadata)
+}
+
+// createNFT mints tx.NFTId to tx.Recipient. Envelope mints are also signed
+// by tx.Sender over message.
+func (bc *Blockchain) createNFT(tx Transaction, message string, details *TokenDetails, class string, metadata map[string]string) (receipt Receipt, err error) {
+	owner, nftId := tx.Recipient, tx.NFTId
+	log.Info("Starting CreateNFT", "owner", owner, "nft_id", nftId, "class", class)
+
+	defer func() {
+		if err != nil {
+			receipt = bc.rejectTransaction(tx, err)
+		}
+	}()
+
+	tokenClass, err := lookupClass(class)
 	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+		return Receipt{}, err
+	}
+	now := time.Now()
+	classInfo, err := newClassInfo(tokenClass, metadata, now)
+	if err != nil {
+		return Receipt{}, err
 	}
-	defer log.Sync()
-
-	log.Info("Starting CreateNFT", "owner", owner, "nft_id", nftId)
 
 	bc.mutex.Lock()
 	defer bc.mutex.Unlock()
 
-	if _, exists := bc.NFTs[nftId]; exists {
-		log.Error("NFT already exists", "nft_id", nftId)
-		return errors.New("NFT already exists")
+	if details, err = bc.mintDetails(owner, details); err != nil {
+		log.Error("Mint to unregistered user", "nft_id", nftId, "owner", owner)
+		return Receipt{}, err
+	}
+	if err := bc.checkMint(tx, message, details, now, false); err != nil {
+		return Receipt{}, err
+	}
+	if details != nil {
+		details.Active = true
+		bc.tokens[nftId] = details
+		bc.emitEvent("token.minted", nftId, map[string]string{
+			"recipient": owner, "username": details.Username,
+			"user_type": details.UserType, "token_type": details.TokenType,
+		})
 	}
 
 	bc.NFTs[nftId] = owner
+	bc.activity[nftId] = newActivity(now)
+	bc.recordMint(owner, now)
+	if classInfo.Class != ledgerID || len(classInfo.Metadata) > 0 || classInfo.ExpiresAt > 0 {
+		bc.classes[nftId] = classInfo
+	}
+	nftsMinted.Inc()
 	log.Info("NFT created successfully", "nft_id", nftId, "owner", owner)
 
-	bc.CurrentTransactions = append(bc.CurrentTransactions, Transaction{
-		Recipient: owner,
-		NFTId:     nftId,
-	})
-	log.Info("Transaction added for NFT creation", "nft_id", nftId, "owner", owner)
+	receipt = bc.addTransaction(tx)
+	bc.linkAuthBurn(tx)
+	log.Info("Transaction added for NFT creation", "nft_id", nftId, "owner", owner, "tx_hash", receipt.TxHash)
+
+	return receipt, nil
+}
 
+// checkMint returns why the mint tx cannot be applied, if it cannot. A mint
+// with a sender must be signed by it over message. Callers must hold
+// bc.mutex.
+func (bc *Blockchain) checkMint(tx Transaction, message string, details *TokenDetails, now time.Time, dryRun bool) error {
+	owner, nftId := tx.Recipient, tx.NFTId
+	if _, exists := bc.NFTs[nftId]; exists {
+		log.Error("NFT already exists", "nft_id", nftId)
+		return errors.New("NFT already exists")
+	}
+	if tx.Sender != "" && !bc.ValidateSignature(tx.Sender, message, tx.Signature) {
+		log.Error("Invalid signature", "sender", tx.Sender, "nft_id", nftId)
+		return ErrBadSignature
+	}
+	if err := bc.checkNonce(tx); err != nil {
+		return err
+	}
+	if err := bc.checkAuthBurn(tx.AuthBurn); err != nil {
+		log.Error("Mint not authorized by an AuthNFT burn", "nft_id", nftId, "error", err)
+		return err
+	}
+	if bc.maxNFTs > 0 && bc.liveNFTs() >= bc.maxNFTs {
+		log.Error("NFT quota exceeded", "tenant", bc.tenant, "max_nfts", bc.maxNFTs)
+		return ErrNFTQuota
+	}
+	if err := bc.checkPolicy(mutation{action: "mint", actor: tx.Sender, target: owner, nftId: nftId, dryRun: dryRun}, now); err != nil {
+		log.Error("Mint rejected by policy", "nft_id", nftId, "owner", owner, "error", err)
+		return err
+	}
+	if details != nil {
+		if err := bc.checkTokenDetails(owner, details); err != nil {
+			log.Error("Invalid token details", "nft_id", nftId, "owner", owner, "error", err)
+			return err
+		}
+	}
 	return nil
 }
 
 // Transfer NFT to host wallet
-func (bc *Blockchain) TransferNFT(sender string, nftId string, signature string) error {
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
+func (bc *Blockchain) TransferNFT(sender string, nftId string, signature string) (Receipt, error) {
+	return bc.TransferNFTTo(sender, bc.HostWallet, nftId, signature)
+}
 
-	log.Info("Starting TransferNFT", "sender", sender, "nft_id", nftId)
+// TransferNFTTo moves an NFT to recipient. The sender signs the recipient
+// address followed by the NFT ID. Only classes with the free transfer rule
+// can go to an address other than the host wallet. A rejected transfer
+// returns its receipt along with the error.
+func (bc *Blockchain) TransferNFTTo(sender, recipient, nftId, signature string) (Receipt, error) {
+	tx := Transaction{Sender: sender, Recipient: recipient, NFTId: nftId, Signature: signature, Type: "transfer"}
+	return bc.transferNFT(tx, recipient+nftId)
+}
+
+// transferNFT applies the transfer tx, whose signature covers message.
+func (bc *Blockchain) transferNFT(tx Transaction, message string) (receipt Receipt, err error) {
+	log.Info("Starting TransferNFT", "sender", tx.Sender, "recipient", tx.Recipient, "nft_id", tx.NFTId)
+
+	defer func() {
+		if err != nil {
+			receipt = bc.rejectTransaction(tx, err)
+		}
+	}()
 
 	bc.mutex.Lock()
 	defer bc.mutex.Unlock()
 
+	if err := bc.checkTransfer(tx, message, time.Now(), false); err != nil {
+		return Receipt{}, err
+	}
+
+	bc.NFTs[tx.NFTId] = tx.Recipient
+	if tx.Recipient == bc.HostWallet {
+		delete(bc.activity, tx.NFTId)
+	}
+	nftsTransferred.Inc()
+	log.Info("NFT transferred successfully", "nft_id", tx.NFTId, "new_owner", tx.Recipient)
+
+	receipt = bc.addTransaction(tx)
+	log.Info("Transaction added for NFT transfer", "nft_id", tx.NFTId, "sender", tx.Sender, "tx_hash", receipt.TxHash)
+
+	return receipt, nil
+}
+
+// checkTransfer returns why the transfer tx, whose signature covers
+// message, cannot be applied, if it cannot. Callers must hold bc.mutex.
+func (bc *Blockchain) checkTransfer(tx Transaction, message string, now time.Time, dryRun bool) error {
+	sender, recipient, nftId := tx.Sender, tx.Recipient, tx.NFTId
 	currentOwner, exists := bc.NFTs[nftId]
 	if !exists {
 		log.Error("NFT does not exist", "nft_id", nftId)
-		return errors.New("NFT does not exist")
+		return ErrNFTNotFound
+	}
+
+	if bc.burned[nftId] {
+		log.Error("NFT has been burned", "nft_id", nftId)
+		return ErrBurned
+	}
+
+	if bc.expired(nftId, now) {
+		log.Error("NFT has expired", "nft_id", nftId)
+		return ErrExpired
 	}
 
 	if currentOwner != sender {
 		log.Error("Sender not authorized", "sender", sender, "current_owner", currentOwner)
-		return errors.New("sender not authorized")
+		return ErrNotOwner
 	}
 
-	if !ValidateSignature(sender, bc.HostWallet+nftId, signature) {
-		log.Error("Invalid signature", "sender", sender, "nft_id", nftId)
-		return errors.New("invalid signature")
+	if err := bc.frozen(nftId, sender, recipient); err != nil {
+		log.Error("Transfer of frozen NFT or wallet", "nft_id", nftId, "sender", sender, "recipient", recipient)
+		return err
 	}
 
-	bc.NFTs[nftId] = bc.HostWallet
-	log.Info("NFT transferred successfully to host wallet", "nft_id", nftId, "new_owner", bc.HostWallet)
+	if recipient != bc.HostWallet {
+		if _, err := publicKeyFromAddress(recipient); err != nil {
+			return fmt.Errorf("invalid recipient: %w", err)
+		}
+	}
+	if err := bc.checkTransferRule(nftId, recipient, false); err != nil {
+		log.Error("Transfer not allowed by token class", "nft_id", nftId, "error", err)
+		return err
+	}
 
-	bc.CurrentTransactions = append(bc.CurrentTransactions, Transaction{
-		Sender:    sender,
-		Recipient: bc.HostWallet,
-		NFTId:     nftId,
-		Signature: signature,
-	})
-	log.Info("Transaction added for NFT transfer to host wallet", "nft_id", nftId, "sender", sender)
+	if !bc.ValidateSignature(sender, message, tx.Signature) {
+		log.Error("Invalid signature", "sender", sender, "nft_id", nftId)
+		return ErrBadSignature
+	}
+	if err := bc.checkNonce(tx); err != nil {
+		return err
+	}
 
+	if err := bc.checkPolicy(mutation{action: "transfer", actor: sender, target: recipient, nftId: nftId, dryRun: dryRun}, now); err != nil {
+		log.Error("Transfer rejected by policy", "nft_id", nftId, "sender", sender, "error", err)
+		return err
+	}
 	return nil
 }
 
 // Proof of Work algorithm
 func (bc *Blockchain) ProofOfWork(lastProof int) int {
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	log.Info("Starting ProofOfWork", "last_proof", lastProof)
 
+	start := time.Now()
 	proof := 0
 	for !bc.ValidProof(lastProof, proof) {
 		proof++
 	}
+	powDuration.ObserveSince(start)
 
 	log.Info("ProofOfWork completed", "proof", proof)
 	return proof
 }
 
-// Validate proof
+// Validate proof: the hash must start with powDifficulty zero hex digits
 func (bc *Blockchain) ValidProof(lastProof, proof int) bool {
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	guess := fmt.Sprintf("%d%d", lastProof, proof)
 	hash := sha256.Sum256([]byte(guess))
-	isValid := hex.EncodeToString(hash[:])[:4] == "0000"
-
-	log.Info("ValidProof check", "last_proof", lastProof, "proof", proof, "is_valid", isValid)
-	return isValid
+	return strings.HasPrefix(hex.EncodeToString(hash[:]), strings.Repeat("0", powDifficulty))
 }
 
-// Mine new block
-func (bc *Blockchain) MineBlock() Block {
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
+// Mine new block. Proof of work runs without holding bc.mutex, so
+// validations and new transactions are not blocked while a block is sealed.
+// The block holds the transactions that were pending when mining started;
+// any added meanwhile wait for the next block.
+func (bc *Blockchain) MineBlock() (Block, error) {
 	log.Info("Starting MineBlock")
 
-	bc.mutex.Lock()
-	defer bc.mutex.Unlock()
+	bc.mineMutex.Lock()
+	defer bc.mineMutex.Unlock()
 
+	bc.mutex.RLock()
 	lastBlock := bc.Chain[len(bc.Chain)-1]
+	pending := len(bc.CurrentTransactions)
+	bc.mutex.RUnlock()
 	log.Info("Last block retrieved", "last_block_index", lastBlock.Index)
 
 	proof := bc.ProofOfWork(lastBlock.Proof)
@@ -224,31 +457,47 @@ func (bc *Blockchain) MineBlock() Block {
 	previousHash := Hash(lastBlock)
 	log.Info("Previous hash calculated", "previous_hash", previousHash)
 
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	// Only a snapshot import can replace the chain under a running miner.
+	if tip := bc.Chain[len(bc.Chain)-1]; tip.Index != lastBlock.Index || Hash(tip) != previousHash {
+		return Block{}, errors.New("chain changed while mining")
+	}
+	if pending > len(bc.CurrentTransactions) {
+		pending = len(bc.CurrentTransactions)
+	}
+	transactions := append([]Transaction(nil), bc.CurrentTransactions[:pending]...)
+	state := nextState(bc.states[len(bc.states)-1], transactions, bc.newStateWallets(len(bc.Chain)+1))
+
 	block := Block{
 		Index:        len(bc.Chain) + 1,
 		Timestamp:    time.Now().String(),
-		Transactions: bc.CurrentTransactions,
+		Transactions: transactions,
 		Proof:        proof,
 		PreviousHash: previousHash,
+		TxRoot:       TxRoot(transactions),
+		TxCount:      len(transactions),
+		StateRoot:    stateRoot(state),
 	}
 
-	bc.CurrentTransactions = nil
+	bc.CurrentTransactions = append([]Transaction(nil), bc.CurrentTransactions[pending:]...)
 	bc.Chain = append(bc.Chain, block)
+	bc.states = append(bc.states, state)
+	bc.indexBlock(block)
+	blocksMined.Inc()
 
 	log.Info("New block mined", "block_index", block.Index)
-	return block
+
+	if err := bc.prune(); err != nil {
+		log.Error("Failed to prune blocks", "error", err)
+	}
+	bc.publishView()
+	return block, nil
 }
 
 // Generate wallet
 func GenerateWallet() (*ecdsa.PrivateKey, string, error) {
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	log.Info("Starting GenerateWallet")
 
 	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
@@ -268,50 +517,39 @@ func GenerateWallet() (*ecdsa.PrivateKey, string, error) {
 	return privateKey, address, nil
 }
 
-// Validate transaction signature
-func ValidateSignature(address string, data string, signature string) bool {
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
+// Validate transaction signature against a wallet registered on this ledger
+func (bc *Blockchain) ValidateSignature(address string, data string, signature string) bool {
 	log.Info("Starting ValidateSignature", "address", address, "data", data)
 
-	publicKey := blockchain.Wallets[address]
+	publicKey, _ := bc.wallet(address)
 	if publicKey == nil {
+		validationFailures.Inc("unknown_wallet")
 		log.Error("Public key not found for address", "address", address)
 		return false
 	}
 
 	sig, err := hex.DecodeString(signature)
 	if err != nil {
+		validationFailures.Inc("bad_signature")
 		log.Error("Failed to decode signature", err)
 		return false
 	}
 
 	hash := sha256.Sum256([]byte(data))
 	isValid := ecdsa.VerifyASN1(publicKey, hash[:], sig)
+	if !isValid {
+		validationFailures.Inc("bad_signature")
+	}
 
 	log.Info("Signature validation result", "is_valid", isValid)
 	return isValid
 }
 
-// Hash block
+// Hash block header
 func Hash(block Block) string {
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	log.Info("Starting Hash", "block_index", block.Index)
 
-	blockBytes, _ := json.Marshal(block)
+	blockBytes, _ := json.Marshal(block.Header())
 	hash := sha256.Sum256(blockBytes)
 	hashString := hex.EncodeToString(hash[:])
 
@@ -319,59 +557,106 @@ func Hash(block Block) string {
 	return hashString
 }
 
-func (bc *Blockchain) BurnNFT(sender string, nftId string, signature string) error {
+// TxRoot hashes a block's transactions so the header commits to its body.
+func TxRoot(transactions []Transaction) string {
+	if transactions == nil {
+		transactions = []Transaction{}
+	}
+	txBytes, _ := json.Marshal(transactions)
+	hash := sha256.Sum256(txBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+// BurnNFT moves nftId to the host wallet and marks it burned. A rejected
+// burn returns its receipt along with the error.
+func (bc *Blockchain) BurnNFT(sender string, nftId string, signature string) (Receipt, error) {
+	tx := Transaction{Sender: sender, Recipient: bc.HostWallet, NFTId: nftId, Signature: signature, Type: "burn"}
+	return bc.burnNFT(tx, nftId+"burn")
+}
+
+// burnNFT applies the burn tx, whose signature covers message.
+func (bc *Blockchain) burnNFT(tx Transaction, message string) (receipt Receipt, err error) {
+	defer func() {
+		if err != nil {
+			receipt = bc.rejectTransaction(tx, err)
+		}
+	}()
+
 	bc.mutex.Lock()
 	defer bc.mutex.Unlock()
 
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+	if err := bc.checkBurn(tx, message, time.Now(), false); err != nil {
+		return Receipt{}, err
 	}
-	defer log.Sync()
 
+	bc.NFTs[tx.NFTId] = bc.HostWallet
+	delete(bc.activity, tx.NFTId)
+	bc.markBurned(tx.NFTId)
+	nftsBurned.Inc()
+	log.Info("NFT ownership transferred to host wallet", "nft_id", tx.NFTId, "new_owner", bc.HostWallet)
+
+	return bc.addTransaction(tx), nil
+}
+
+// checkBurn returns why the burn tx, whose signature covers message, cannot
+// be applied, if it cannot. Callers must hold bc.mutex.
+func (bc *Blockchain) checkBurn(tx Transaction, message string, now time.Time, dryRun bool) error {
+	sender, nftId := tx.Sender, tx.NFTId
 	currentOwner, exists := bc.NFTs[nftId]
 	if !exists {
 		log.Error("NFT does not exist")
-		return errors.New("NFT does not exist")
+		return ErrNFTNotFound
+	}
+
+	if bc.burned[nftId] {
+		log.Error("NFT has been burned")
+		return ErrBurned
 	}
 
 	if currentOwner != sender {
 		log.Error("Sender not authorized")
-		return errors.New("sender not authorized")
+		return ErrNotOwner
 	}
 
-	if !ValidateSignature(sender, nftId+"burn", signature) {
-		log.Error("Invalid signature")
-		return errors.New("invalid signature")
+	if err := bc.frozen(nftId, sender); err != nil {
+		log.Error("Burn of frozen NFT or wallet", "nft_id", nftId, "sender", sender)
+		return err
+	}
+
+	if err := bc.checkTransferRule(nftId, bc.HostWallet, true); err != nil {
+		log.Error("Burn not allowed by token class", "nft_id", nftId, "error", err)
+		return err
 	}
 
-	bc.NFTs[nftId] = bc.HostWallet
-	log.Info("NFT ownership transferred to host wallet", "nft_id", nftId, "new_owner", bc.HostWallet)
+	if !bc.ValidateSignature(sender, message, tx.Signature) {
+		log.Error("Invalid signature")
+		return ErrBadSignature
+	}
+	if err := bc.checkNonce(tx); err != nil {
+		return err
+	}
 
-	bc.CurrentTransactions = append(bc.CurrentTransactions, Transaction{
-		Sender:    sender,
-		Recipient: bc.HostWallet,
-		NFTId:     nftId,
-		Signature: signature,
-	})
+	if err := bc.checkPolicy(mutation{action: "burn", actor: sender, target: bc.HostWallet, nftId: nftId, dryRun: dryRun}, now); err != nil {
+		log.Error("Burn rejected by policy", "nft_id", nftId, "sender", sender, "error", err)
+		return err
+	}
 	return nil
 }
+
+// markBurned records that nftId has been burned and deactivates its token
+// details. Callers must hold bc.mutex.
+func (bc *Blockchain) markBurned(nftId string) {
+	bc.burned[nftId] = true
+	bc.deactivateToken(nftId)
+}
+
 func TransferNFTHandler(w http.ResponseWriter, r *http.Request) {
 	type Request struct {
 		Sender       string `json:"sender"`
 		NFTId        string `json:"nft_id"`
 		SignedNFTToken string `json:"signed_nfttoken"`
+		Recipient    string `json:"recipient"` // Optional; defaults to the host wallet
 	}
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	var req Request
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -379,23 +664,29 @@ func TransferNFTHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	recipient := blockchain.HostWallet // All NFTs are transferred to the host wallet
+	bc := ledgerFor(r)
+	recipient := req.Recipient
+	if recipient == "" {
+		recipient = bc.HostWallet
+	}
 
 	// Validate the signed NFT token
-	if !ValidateSignature(req.Sender, blockchain.HostWallet+req.NFTId, req.SignedNFTToken) {
+	if !bc.ValidateSignature(req.Sender, recipient+req.NFTId, req.SignedNFTToken) {
 		http.Error(w, "Invalid signed NFT token", http.StatusBadRequest)
 		log.Error("Invalid signed NFT token", "sender", req.Sender, "nft_id", req.NFTId)
 		return
 	}
 
-	if err := blockchain.TransferNFT(req.Sender, req.NFTId, req.SignedNFTToken); err != nil {
+	receipt, err := bc.TransferNFTTo(req.Sender, recipient, req.NFTId, req.SignedNFTToken)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		log.Error("Failed to transfer NFT", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	log.Info("NFT transferred to host wallet", "nft_id", req.NFTId, "host_wallet", recipient)
+	log.Info("NFT transferred", "nft_id", req.NFTId, "recipient", recipient)
+	json.NewEncoder(w).Encode(map[string]Receipt{"receipt": receipt})
 	//burn the NFT
 }
 
@@ -405,21 +696,16 @@ func GetNFTOwnerHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req Request
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		log.Error("Failed to decode request body", err)
 		return
 	}
 
-	owner, exists := blockchain.NFTs[req.NFTId]
+	bc := ledgerFor(r)
+	bc.mutex.RLock()
+	owner, exists := bc.NFTs[req.NFTId]
+	bc.mutex.RUnlock()
 	if !exists {
 		http.Error(w, "NFT not found", http.StatusNotFound)
 		log.Error("NFT not found", "nft_id", req.NFTId)
@@ -437,14 +723,6 @@ func BurnNFTHandler(w http.ResponseWriter, r *http.Request) {
 		Signature string `json:"signature"`
 	}
 
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	var req Request
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -452,22 +730,18 @@ func BurnNFTHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := blockchain.BurnNFT(req.Sender, req.NFTId, req.Signature); err != nil {
+	receipt, err := ledgerFor(r).BurnNFT(req.Sender, req.NFTId, req.Signature)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		log.Error("Failed to burn NFT", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]Receipt{"receipt": receipt})
 }
 
 func GenerateWalletHandler(w http.ResponseWriter, r *http.Request) {
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
 	privateKey, address, err := GenerateWallet()
 	if err != nil {
 		log.Error("Failed to generate wallet", err)
@@ -475,9 +749,11 @@ func GenerateWalletHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	blockchain.mutex.Lock()
-	blockchain.Wallets[address] = &privateKey.PublicKey
-	blockchain.mutex.Unlock()
+	if err := ledgerFor(r).registerWallet(address, &privateKey.PublicKey); err != nil {
+		log.Error("Failed to register wallet", "error", err)
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
 
 	privateKeyBytes, err := x509.MarshalECPrivateKey(privateKey)
 	if err != nil {
@@ -496,16 +772,14 @@ func GenerateWalletHandler(w http.ResponseWriter, r *http.Request) {
 func CreateNFTHandler(w http.ResponseWriter, r *http.Request) {
 
 	type Request struct {
-		Owner string `json:"owner"`
-	}
-
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+		Owner     string `json:"owner"`
+		Username  string `json:"username"`
+		UserType  string `json:"user_type"`
+		TokenType string `json:"token_type"`
+		Class     string            `json:"class"`
+		Metadata  map[string]string `json:"metadata"`
+		AuthBurn  *BurnProof        `json:"auth_burn"` // Proof of the AuthNFT burn behind a ReqNFT
 	}
-	defer log.Sync()
 
 	var req Request
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -514,10 +788,23 @@ func CreateNFTHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := checkMintAllowed(r, req.Class); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	// Generate a unique NFT ID
 	nftId := fmt.Sprintf("nft-%d", time.Now().UnixNano())
 
-	if err := blockchain.CreateNFT(req.Owner, nftId); err != nil {
+	// Token details are optional; when any are given they are all validated.
+	// The auth ledger binds a registered user's details to every mint.
+	var details *TokenDetails
+	if req.Username != "" || req.UserType != "" || req.TokenType != "" {
+		details = &TokenDetails{Username: req.Username, UserType: req.UserType, TokenType: req.TokenType}
+	}
+
+	receipt, err := ledgerFor(r).CreateNFT(req.Owner, nftId, req.AuthBurn, details, req.Class, req.Metadata)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		log.Error("Failed to create NFT", err)
 		return
@@ -525,25 +812,18 @@ func CreateNFTHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusCreated)
 	log.Info("NFT created")
-	json.NewEncoder(w).Encode(map[string]string{"nft_id": nftId})
+	json.NewEncoder(w).Encode(map[string]interface{}{"nft_id": nftId, "receipt": receipt})
 }
 
 // Add validation handler
 func ValidateNFTOwnerHandler(w http.ResponseWriter, r *http.Request) {
 
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	log.Info("Starting ValidateNFTOwnerHandler")
 
 	type Request struct {
-		NFTId   string `json:"nft_id"`
-		Address string `json:"address"`
+		NFTId            string `json:"nft_id"`
+		Address          string `json:"address"`
+		MinConfirmations int    `json:"min_confirmations"` // Optional; 0 accepts pending ownership
 	}
 
 	var req Request
@@ -561,11 +841,21 @@ func ValidateNFTOwnerHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Info("Received request", "nft_id", req.NFTId, "address", req.Address)
 
-	blockchain.mutex.Lock()
-	owner, exists := blockchain.NFTs[req.NFTId]
-	blockchain.mutex.Unlock()
+	bc := ledgerFor(r)
+	bc.mutex.RLock()
+	owner, exists := bc.NFTs[req.NFTId]
+	owned := exists && bc.ownedBy(owner, req.Address)
+	expired := bc.expired(req.NFTId, time.Now())
+	frozen := bc.frozen(req.NFTId, owner, req.Address)
+	finality := bc.finality(req.NFTId)
+	unconfirmed := finality.confirmed(req.MinConfirmations)
+	if owned && !expired && frozen == nil && unconfirmed == nil {
+		bc.touchNFT(req.NFTId)
+	}
+	bc.mutex.RUnlock()
 
 	if !exists {
+		validationFailures.Inc("nft_not_found")
 		log.Error("NFT not found", "nft_id", req.NFTId)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"valid": false,
@@ -576,9 +866,29 @@ func ValidateNFTOwnerHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Info("NFT found", "nft_id", req.NFTId, "owner", owner)
 
-	// Check if the provided address matches the owner
-	isValid := owner == req.Address
-	if !isValid {
+	if expired {
+		validationFailures.Inc("expired")
+		log.Error("NFT has expired", "nft_id", req.NFTId)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"valid": false,
+			"error": "NFT has expired",
+		})
+		return
+	}
+
+	if frozen != nil {
+		validationFailures.Inc("frozen")
+		log.Error("NFT or wallet is frozen", "nft_id", req.NFTId, "address", req.Address)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"valid": false,
+			"error": frozen.Error(),
+		})
+		return
+	}
+
+	// Check if the provided address matches the owner or is its master
+	if !owned {
+		validationFailures.Inc("not_owner")
 		log.Error("Address does not match the owner", "nft_id", req.NFTId, "address", req.Address)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"valid": false,
@@ -587,9 +897,26 @@ func ValidateNFTOwnerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if unconfirmed != nil {
+		validationFailures.Inc("unconfirmed")
+		log.Error("NFT ownership is not final", "nft_id", req.NFTId, "confirmations", finality.Confirmations,
+			"min_confirmations", req.MinConfirmations)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"valid":         false,
+			"error":         unconfirmed.Error(),
+			"status":        finality.Status,
+			"block_index":   finality.BlockIndex,
+			"confirmations": finality.Confirmations,
+		})
+		return
+	}
+
 	log.Info("Ownership validated successfully", "nft_id", req.NFTId, "address", req.Address)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"valid": true,
+		"valid":         true,
+		"status":        finality.Status,
+		"block_index":   finality.BlockIndex,
+		"confirmations": finality.Confirmations,
 	})
 }
 
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Per-session addresses
+//
+// A wallet can register as a master by publishing a 32-byte chain code. Child
+// addresses are derived from the master public key with BIP32 non-hardened
+// derivation on P-256:
+//
+//	I        = HMAC-SHA512(chain code, compressed(K) || uint32be(index))
+//	child K  = I[:32]·G + K
+//	child cc = I[32:]
+//
+// and the holder of the master private key k signs as a child with
+// k + I[:32] mod n. Without the chain code a child address cannot be linked
+// to its master, so ReqNFTs minted to children do not link a user's sessions.
+// The ledger keeps the link privately and lets the master prove ownership of
+// NFTs its children hold.
+
+// hdChainCodeSize is the length of a chain code in bytes.
+const hdChainCodeSize = 32
+
+// ChildLink records the master and derivation path of a child address.
+type ChildLink struct {
+	Master string `json:"master"`
+	Path   string `json:"path"`
+}
+
+// MasterMessage is the data a master wallet signs to register chainCode.
+func MasterMessage(chainCode string) string {
+	return "master:" + chainCode
+}
+
+// deriveChild returns the non-hardened child at index of the extended public
+// key (pub, chainCode).
+func deriveChild(pub *ecdsa.PublicKey, chainCode []byte, index uint32) (*ecdsa.PublicKey, []byte, error) {
+	if index >= 1<<31 {
+		return nil, nil, errors.New("hardened derivation is not supported")
+	}
+	curve := elliptic.P256()
+	data := binary.BigEndian.AppendUint32(elliptic.MarshalCompressed(curve, pub.X, pub.Y), index)
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	// BIP32 skips indexes whose tweak is out of range; they are vanishingly
+	// rare, so they are reported rather than skipped.
+	if new(big.Int).SetBytes(sum[:32]).Cmp(curve.Params().N) >= 0 {
+		return nil, nil, fmt.Errorf("index %d derives an invalid key", index)
+	}
+	x, y := curve.ScalarBaseMult(sum[:32])
+	x, y = curve.Add(x, y, pub.X, pub.Y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, nil, fmt.Errorf("index %d derives an invalid key", index)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, sum[32:], nil
+}
+
+// parseHDPath parses a non-hardened path such as "m/0/7" or "0/7".
+func parseHDPath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimPrefix(path, "m/"), "/")
+	indexes := make([]uint32, 0, len(parts))
+	for _, part := range parts {
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: indexes must be non-hardened integers", path)
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+// deriveAddress returns the address at path below the master address.
+func deriveAddress(master string, chainCode []byte, path string) (string, error) {
+	indexes, err := parseHDPath(path)
+	if err != nil {
+		return "", err
+	}
+	pub, err := publicKeyFromAddress(master)
+	if err != nil {
+		return "", err
+	}
+	if pub.Curve != elliptic.P256() {
+		return "", errors.New("master key is not P-256")
+	}
+	for _, index := range indexes {
+		if pub, chainCode, err = deriveChild(pub, chainCode, index); err != nil {
+			return "", err
+		}
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(der), nil
+}
+
+// RegisterMaster records chainCode for a registered wallet, signed by that
+// wallet over MasterMessage. A master's chain code cannot be replaced.
+func (bc *Blockchain) RegisterMaster(address, chainCode, signature string) error {
+	code, err := hex.DecodeString(chainCode)
+	if err != nil || len(code) != hdChainCodeSize {
+		return fmt.Errorf("chain code must be %d hex-encoded bytes", hdChainCodeSize)
+	}
+	if !bc.ValidateSignature(address, MasterMessage(chainCode), signature) {
+		return ErrBadSignature
+	}
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	if _, exists := bc.masters[address]; exists {
+		return errors.New("master is already registered")
+	}
+	if _, isChild := bc.children[address]; isChild {
+		return errors.New("a child address cannot be a master")
+	}
+	bc.masters[address] = chainCode
+	log.Info("Master key registered", "address", address)
+	return nil
+}
+
+// RegisterChild checks that address is the child at path below master and
+// registers it as a wallet. Deriving a matching address needs the master's
+// chain code, which only its holder knows.
+func (bc *Blockchain) RegisterChild(master, path, address string) error {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	chainCode, ok := bc.masters[master]
+	if !ok {
+		return errors.New("master is not registered")
+	}
+	code, _ := hex.DecodeString(chainCode)
+	derived, err := deriveAddress(master, code, path)
+	if err != nil {
+		return err
+	}
+	if derived != address {
+		validationFailures.Inc("bad_child")
+		return errors.New("address is not the child of master at path")
+	}
+	if link, exists := bc.children[address]; exists && link.Master == master {
+		return nil
+	}
+	if err := bc.checkPolicy(mutation{action: "register", actor: master, target: address}, time.Now()); err != nil {
+		return err
+	}
+
+	pub, err := publicKeyFromAddress(address)
+	if err != nil {
+		return err
+	}
+	if _, registered := bc.wallet(address); !registered {
+		if err := bc.registerWallet(address, pub); err != nil {
+			return err
+		}
+	}
+	bc.children[address] = ChildLink{Master: master, Path: path}
+	log.Info("Child address registered", "path", path)
+	return nil
+}
+
+// ownedBy reports whether address owns an NFT held by owner, either directly
+// or as the master of owner. Callers must hold bc.mutex.
+func (bc *Blockchain) ownedBy(owner, address string) bool {
+	if owner == address {
+		return true
+	}
+	link, ok := bc.children[owner]
+	return ok && link.Master == address
+}
+
+// RegisterMasterHandler registers a master key: POST {address, chain_code,
+// signature}, signed by the wallet over MasterMessage(chain_code).
+func RegisterMasterHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Address   string `json:"address"`
+		ChainCode string `json:"chain_code"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	if err := ledgerFor(r).RegisterMaster(req.Address, req.ChainCode, req.Signature); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to register master key", "address", req.Address, "error", err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]bool{"registered": true})
+}
+
+// RegisterChildHandler registers a per-session child address: POST {master,
+// path, address}.
+func RegisterChildHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Master  string `json:"master"`
+		Path    string `json:"path"`
+		Address string `json:"address"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	if err := ledgerFor(r).RegisterChild(req.Master, req.Path, req.Address); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrWalletQuota) {
+			status = http.StatusTooManyRequests
+		}
+		http.Error(w, err.Error(), status)
+		log.Error("Failed to register child address", "error", err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]bool{"registered": true})
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ledgerID names this ledger ("auth" or "req") in signed artifacts.
+var ledgerID = "ledger"
+
+// SetLedgerID sets the identifier this node reports in signed artifacts.
+func SetLedgerID(id string) {
+	ledgerID = id
+}
+
+// InitHostWallet loads the default ledger's host key from path, creating it
+// if the file does not exist, and registers it as the host wallet. An empty
+// path generates a key that lives only as long as the process.
+func InitHostWallet(path string) (string, error) {
+	return blockchain.initHostKey(path)
+}
+
+// initHostKey loads or creates bc's host key and registers its wallet.
+func (bc *Blockchain) initHostKey(path string) (string, error) {
+	var (
+		key *ecdsa.PrivateKey
+		err error
+	)
+	if path == "" {
+		key, _, err = GenerateWallet()
+	} else {
+		key, err = loadOrCreateHostKey(path)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	address, err := addressFromPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", err
+	}
+
+	bc.mutex.Lock()
+	bc.HostWallet = address
+	bc.hostKey = key
+	bc.mutex.Unlock()
+	bc.addWallet(address, &key.PublicKey)
+
+	return address, nil
+}
+
+func loadOrCreateHostKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key, _, err := GenerateWallet()
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		block := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+		if err := os.WriteFile(path, block, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write host key: %w", err)
+		}
+		log.Info("Generated new host key", "path", path)
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read host key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, errors.New("host key file is not a PEM encoded EC private key")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+func addressFromPublicKey(pub *ecdsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(der), nil
+}
+
+func publicKeyFromAddress(address string) (*ecdsa.PublicKey, error) {
+	der, err := hex.DecodeString(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address encoding: %w", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address: %w", err)
+	}
+	ecPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("address is not an ECDSA public key")
+	}
+	return ecPub, nil
+}
+
+// hostSign signs data with the ledger's host key, hashing it with SHA-256 the
+// same way ValidateSignature verifies wallet signatures.
+func (bc *Blockchain) hostSign(data []byte) (string, error) {
+	if bc.hostKey == nil {
+		return "", errors.New("host key not initialized")
+	}
+	hash := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, bc.hostKey, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sig), nil
+}
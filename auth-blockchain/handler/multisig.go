@@ -0,0 +1,471 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// Proposal actions.
+const (
+	ProposalMint         = "mint"          // Mint an NFT to Target
+	ProposalBurn         = "burn"          // Burn NFT Target on its owner's behalf
+	ProposalRegisterUser = "register_user" // Register the user at address Target
+)
+
+// Proposal states.
+const (
+	proposalPending   = "pending"
+	proposalExecuting = "executing"
+	proposalExecuted  = "executed"
+	proposalFailed    = "failed"
+	proposalExpired   = "expired"
+)
+
+// MultisigConfig is the m-of-n admin wallet that authorizes privileged
+// operations. It is loaded from the JSON file in MULTISIG_FILE, for example:
+//
+//	{"threshold": 2, "signers": ["3059...", "3059...", "3059..."], "max_lifetime": "24h"}
+//
+// While it is configured, user registration and admin_mint classes can only
+// go through proposals, not the ADMIN_TOKEN bearer token.
+type MultisigConfig struct {
+	Threshold   int      `json:"threshold"`
+	Signers     []string `json:"signers"`
+	MaxLifetime string   `json:"max_lifetime,omitempty"` // Default 24h
+
+	maxLifetime time.Duration
+	keys        map[string]*ecdsa.PublicKey
+}
+
+// Proposal is a privileged operation waiting for signatures from the admin
+// wallet. Every signer signs the same ProposalMessage, and the proposal runs
+// as soon as Threshold signers have signed. A proposal is identified by the
+// hash of its message, so an executed proposal cannot be proposed again
+// before it would have expired.
+type Proposal struct {
+	ID         string            `json:"proposal_id"`
+	Action     string            `json:"action"`
+	Target     string            `json:"target"`
+	Username   string            `json:"username,omitempty"`
+	UserType   string            `json:"user_type,omitempty"`
+	TokenType  string            `json:"token_type,omitempty"`
+	Class      string            `json:"class,omitempty"`
+	ExpiresAt  int64             `json:"expires_at"` // Unix seconds
+	Signatures map[string]string `json:"signatures"` // Signer address to signature
+	Status     string            `json:"status"`
+	NFTId      string            `json:"nft_id,omitempty"`  // Minted NFT, once a mint has executed
+	TxHash     string            `json:"tx_hash,omitempty"` // Mint or burn transaction, once executed
+	Error      string            `json:"error,omitempty"`   // Why execution failed
+}
+
+// ProposalMessage is the data each signer signs to approve a proposal on
+// tenant ("" for the default ledger). The tenant is signed because one admin
+// wallet governs every tenant, so a proposal must not be replayable on
+// another one.
+func ProposalMessage(p Proposal, tenant string) string {
+	return fmt.Sprintf("propose:%s:%s:%s:%s:%s:%s:%s:%s:%d",
+		ledgerID, tenant, p.Action, p.Target, p.Username, p.UserType, p.TokenType, p.Class, p.ExpiresAt)
+}
+
+// ExpireProposalMessage is the data a signer signs to cancel a proposal on
+// tenant.
+func ExpireProposalMessage(proposalID, tenant string) string {
+	return fmt.Sprintf("expire:%s:%s:%s", ledgerID, tenant, proposalID)
+}
+
+// multisig is set once at startup by LoadMultisig and read-only afterwards.
+// The same admin wallet governs the default ledger and every tenant.
+var multisig *MultisigConfig
+
+// LoadMultisig reads and validates the admin wallet in the JSON file at path.
+func LoadMultisig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read multisig file: %w", err)
+	}
+	var config MultisigConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse multisig file: %w", err)
+	}
+
+	config.keys = make(map[string]*ecdsa.PublicKey, len(config.Signers))
+	for _, signer := range config.Signers {
+		key, err := publicKeyFromAddress(signer)
+		if err != nil {
+			return fmt.Errorf("signer %s: %w", signer, err)
+		}
+		config.keys[signer] = key
+	}
+	if config.Threshold < 1 || config.Threshold > len(config.keys) {
+		return fmt.Errorf("threshold must be between 1 and the %d distinct signers", len(config.keys))
+	}
+	config.maxLifetime = 24 * time.Hour
+	if config.MaxLifetime != "" {
+		config.maxLifetime, err = time.ParseDuration(config.MaxLifetime)
+		if err != nil || config.maxLifetime <= 0 {
+			return fmt.Errorf("invalid max_lifetime %q", config.MaxLifetime)
+		}
+	}
+
+	multisig = &config
+	log.Info("Multisig admin wallet loaded", "path", path, "threshold", config.Threshold, "signers", len(config.keys))
+	return nil
+}
+
+// multisigRequired reports whether privileged operations must go through
+// proposals.
+func multisigRequired() bool {
+	return multisig != nil
+}
+
+// verifySigner checks that signer belongs to the admin wallet and signed
+// message.
+func verifySigner(signer, message, signature string) error {
+	key, ok := multisig.keys[signer]
+	if !ok {
+		return errors.New("not a multisig signer")
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		validationFailures.Inc("bad_signature")
+		return ErrBadSignature
+	}
+	hash := sha256.Sum256([]byte(message))
+	if !ecdsa.VerifyASN1(key, hash[:], sig) {
+		validationFailures.Inc("bad_signature")
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// expireDue marks pending proposals past their expiry. Callers must hold
+// bc.mutex for writing.
+func (bc *Blockchain) expireDue(now time.Time) {
+	for _, p := range bc.proposals {
+		if p.Status == proposalPending && now.Unix() >= p.ExpiresAt {
+			p.Status = proposalExpired
+			bc.emitEvent("proposal.expired", "", map[string]string{"proposal_id": p.ID})
+		}
+	}
+}
+
+// Propose records a new proposal with its first signature.
+func (bc *Blockchain) Propose(p Proposal, signer, signature string, now time.Time) (*Proposal, error) {
+	if multisig == nil {
+		return nil, errors.New("multisig is not configured")
+	}
+	switch p.Action {
+	case ProposalMint, ProposalBurn, ProposalRegisterUser:
+	default:
+		return nil, fmt.Errorf("unknown action %q", p.Action)
+	}
+	if p.Target == "" {
+		return nil, errors.New("target is required")
+	}
+	expires := time.Unix(p.ExpiresAt, 0)
+	if !expires.After(now) || expires.Sub(now) > multisig.maxLifetime {
+		return nil, fmt.Errorf("expires_at must be in the future and within %s", multisig.maxLifetime)
+	}
+	if err := verifySigner(signer, ProposalMessage(p, bc.tenant), signature); err != nil {
+		return nil, err
+	}
+
+	id := sha256.Sum256([]byte(ProposalMessage(p, bc.tenant)))
+	p.ID = hex.EncodeToString(id[:])
+	p.Signatures = map[string]string{signer: signature}
+	p.Status = proposalPending
+	p.NFTId, p.TxHash, p.Error = "", "", ""
+
+	bc.mutex.Lock()
+	// Expired proposals no longer need to be remembered to prevent replays.
+	for id, existing := range bc.proposals {
+		if now.Unix() >= existing.ExpiresAt {
+			delete(bc.proposals, id)
+		}
+	}
+	if _, exists := bc.proposals[p.ID]; exists {
+		bc.mutex.Unlock()
+		return nil, errors.New("proposal already exists")
+	}
+	bc.proposals[p.ID] = &p
+	ready := bc.readyToExecute(&p)
+	result := p
+	bc.mutex.Unlock()
+
+	bc.emitEvent("proposal.created", "", map[string]string{
+		"proposal_id": p.ID, "action": p.Action, "target": p.Target, "signer": signer,
+	})
+	if !ready {
+		return &result, nil
+	}
+	return bc.finishProposal(&p), nil
+}
+
+// SignProposal adds signer's signature to a pending proposal and executes it
+// once the threshold is reached.
+func (bc *Blockchain) SignProposal(proposalID, signer, signature string, now time.Time) (*Proposal, error) {
+	if multisig == nil {
+		return nil, errors.New("multisig is not configured")
+	}
+
+	bc.mutex.Lock()
+	bc.expireDue(now)
+	p, exists := bc.proposals[proposalID]
+	if !exists {
+		bc.mutex.Unlock()
+		return nil, errors.New("proposal does not exist")
+	}
+	if p.Status != proposalPending {
+		bc.mutex.Unlock()
+		return nil, fmt.Errorf("proposal is %s", p.Status)
+	}
+	if err := verifySigner(signer, ProposalMessage(*p, bc.tenant), signature); err != nil {
+		bc.mutex.Unlock()
+		return nil, err
+	}
+	p.Signatures[signer] = signature
+	ready := bc.readyToExecute(p)
+	result := *p
+	bc.mutex.Unlock()
+
+	bc.emitEvent("proposal.signed", "", map[string]string{"proposal_id": p.ID, "signer": signer})
+	if !ready {
+		return &result, nil
+	}
+	return bc.finishProposal(p), nil
+}
+
+// readyToExecute reports whether p has reached the threshold and, if so,
+// moves it out of pending so no other caller executes it too. Callers must
+// hold bc.mutex for writing.
+func (bc *Blockchain) readyToExecute(p *Proposal) bool {
+	if len(p.Signatures) < multisig.Threshold {
+		return false
+	}
+	p.Status = proposalExecuting
+	return true
+}
+
+// finishProposal executes p and records the outcome. It runs without
+// bc.mutex held because the operations take the lock themselves.
+func (bc *Blockchain) finishProposal(p *Proposal) *Proposal {
+	bc.mutex.RLock()
+	proposal := *p
+	bc.mutex.RUnlock()
+
+	receipt, err := bc.executeProposal(proposal)
+	nftId := receipt.NFTId
+
+	bc.mutex.Lock()
+	p.NFTId = nftId
+	p.TxHash = receipt.TxHash
+	p.Status = proposalExecuted
+	if err != nil {
+		p.Status = proposalFailed
+		p.Error = err.Error()
+	}
+	result := *p
+	bc.mutex.Unlock()
+
+	if err != nil {
+		log.Error("Proposal failed", "proposal_id", p.ID, "action", p.Action, "error", err)
+		bc.emitEvent("proposal.failed", nftId, map[string]string{"proposal_id": p.ID, "error": err.Error()})
+	} else {
+		log.Info("Proposal executed", "proposal_id", p.ID, "action", p.Action)
+		bc.emitEvent("proposal.executed", nftId, map[string]string{"proposal_id": p.ID, "action": p.Action})
+	}
+	return &result
+}
+
+// executeProposal runs an approved proposal and returns the receipt of its
+// mint or burn. User registrations have no transaction.
+func (bc *Blockchain) executeProposal(p Proposal) (Receipt, error) {
+	switch p.Action {
+	case ProposalMint:
+		var details *TokenDetails
+		if p.Username != "" || p.UserType != "" || p.TokenType != "" {
+			details = &TokenDetails{Username: p.Username, UserType: p.UserType, TokenType: p.TokenType}
+		}
+		nftId := fmt.Sprintf("nft-%d", time.Now().UnixNano())
+		receipt, err := bc.CreateNFT(p.Target, nftId, nil, details, p.Class, nil)
+		if err != nil {
+			// Nothing was minted; keep the hash so the rejection can be looked up.
+			return Receipt{TxHash: receipt.TxHash}, err
+		}
+		return receipt, nil
+
+	case ProposalBurn:
+		bc.mutex.Lock()
+		defer bc.mutex.Unlock()
+		owner, exists := bc.NFTs[p.Target]
+		if !exists {
+			return Receipt{NFTId: p.Target}, ErrNFTNotFound
+		}
+		if bc.burned[p.Target] {
+			return Receipt{NFTId: p.Target}, ErrBurned
+		}
+		if err := bc.frozen(p.Target, owner); err != nil {
+			return Receipt{NFTId: p.Target}, err
+		}
+		if err := bc.checkPolicy(mutation{action: "burn", actor: bc.HostWallet, target: owner, nftId: p.Target}, time.Now()); err != nil {
+			return Receipt{NFTId: p.Target}, err
+		}
+		return bc.hostBurn(p.Target, owner)
+
+	default:
+		return Receipt{}, bc.RegisterUser(p.Target, p.Username, p.UserType)
+	}
+}
+
+// ExpireProposal cancels a pending proposal with a signature from any
+// signer over ExpireProposalMessage.
+func (bc *Blockchain) ExpireProposal(proposalID, signer, signature string) error {
+	if multisig == nil {
+		return errors.New("multisig is not configured")
+	}
+	if err := verifySigner(signer, ExpireProposalMessage(proposalID, bc.tenant), signature); err != nil {
+		return err
+	}
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	p, exists := bc.proposals[proposalID]
+	if !exists {
+		return errors.New("proposal does not exist")
+	}
+	if p.Status != proposalPending {
+		return fmt.Errorf("proposal is %s", p.Status)
+	}
+	p.Status = proposalExpired
+	bc.emitEvent("proposal.expired", "", map[string]string{"proposal_id": p.ID, "signer": signer})
+	return nil
+}
+
+// Proposals returns the proposals with the given status, or all of them for
+// an empty status, soonest expiry first.
+func (bc *Blockchain) Proposals(status string, now time.Time) []Proposal {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	bc.expireDue(now)
+
+	result := []Proposal{}
+	for _, p := range bc.proposals {
+		if status == "" || p.Status == status {
+			copied := *p
+			copied.Signatures = make(map[string]string, len(p.Signatures))
+			for signer, signature := range p.Signatures {
+				copied.Signatures[signer] = signature
+			}
+			result = append(result, copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ExpiresAt < result[j].ExpiresAt })
+	return result
+}
+
+// ProposeHandler creates a proposal: POST {action, target, username?,
+// user_type?, token_type?, class?, expires_at, signer, signature}.
+func ProposeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Proposal
+		Signer    string `json:"signer"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	proposal, err := ledgerFor(r).Propose(req.Proposal, req.Signer, req.Signature, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to create proposal", "action", req.Action, "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(proposal)
+}
+
+// ApproveProposalHandler adds a signature: POST {proposal_id, signer,
+// signature}.
+func ApproveProposalHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ProposalID string `json:"proposal_id"`
+		Signer     string `json:"signer"`
+		Signature  string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	proposal, err := ledgerFor(r).SignProposal(req.ProposalID, req.Signer, req.Signature, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to approve proposal", "proposal_id", req.ProposalID, "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(proposal)
+}
+
+// ExpireProposalHandler cancels a pending proposal: POST {proposal_id,
+// signer, signature}.
+func ExpireProposalHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ProposalID string `json:"proposal_id"`
+		Signer     string `json:"signer"`
+		Signature  string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	if err := ledgerFor(r).ExpireProposal(req.ProposalID, req.Signer, req.Signature); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to expire proposal", "proposal_id", req.ProposalID, "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"expired": true})
+}
+
+// ProposalsHandler lists proposals, optionally filtered by the status query
+// parameter.
+func ProposalsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ledgerFor(r).Proposals(r.URL.Query().Get("status"), time.Now()))
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Policy rule kinds.
+const (
+	// ruleMaxLive caps the live NFTs an address may hold when it is minted
+	// another one. Max 1 on the auth ledger means one active AuthNFT per
+	// auth address.
+	ruleMaxLive = "max_live_per_owner"
+	// ruleMintRate caps mints to one address within Window.
+	ruleMintRate = "mint_rate"
+	// ruleDenyList rejects any mutation whose actor or target is listed.
+	ruleDenyList = "deny_list"
+)
+
+// PolicyRule is one declarative rule. Rules are loaded from a JSON array in
+// POLICY_FILE, for example:
+//
+//	[{"id": "one-session", "kind": "max_live_per_owner", "max": 1},
+//	 {"id": "mint-burst", "kind": "mint_rate", "max": 5, "window": "1m"},
+//	 {"id": "blocked", "kind": "deny_list", "addresses": ["3059..."]}]
+type PolicyRule struct {
+	ID        string   `json:"id"`
+	Kind      string   `json:"kind"`
+	Max       int      `json:"max,omitempty"`
+	Window    string   `json:"window,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+
+	window time.Duration
+	denied map[string]bool
+}
+
+// PolicyViolation is returned when a mutation breaks a rule.
+type PolicyViolation struct {
+	RuleID  string
+	Message string
+}
+
+func (v *PolicyViolation) Error() string {
+	return fmt.Sprintf("policy %s: %s", v.RuleID, v.Message)
+}
+
+// mutation describes a state change for policy evaluation. actor is the
+// address that initiated it, if any, and target the address it grants to or
+// acts on.
+type mutation struct {
+	action string // "mint", "transfer", "burn", "approve", "operate" or "register"
+	actor  string
+	target string
+	nftId  string
+	dryRun bool // Evaluate only; a violation is not counted or published
+}
+
+// policyRules is set once at startup by LoadPolicy and read-only afterwards.
+// The same rules apply to the default ledger and every tenant.
+var policyRules []PolicyRule
+
+// LoadPolicy reads and validates the rules in the JSON file at path.
+func LoadPolicy(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read policy file: %w", err)
+	}
+	var rules []PolicyRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("failed to parse policy file: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range rules {
+		rule := &rules[i]
+		if rule.ID == "" {
+			return fmt.Errorf("rule %d has no id", i)
+		}
+		if seen[rule.ID] {
+			return fmt.Errorf("rule id %s is used twice", rule.ID)
+		}
+		seen[rule.ID] = true
+
+		switch rule.Kind {
+		case ruleMaxLive:
+			if rule.Max < 1 {
+				return fmt.Errorf("rule %s: max must be at least 1", rule.ID)
+			}
+		case ruleMintRate:
+			if rule.Max < 1 {
+				return fmt.Errorf("rule %s: max must be at least 1", rule.ID)
+			}
+			rule.window, err = time.ParseDuration(rule.Window)
+			if err != nil || rule.window <= 0 {
+				return fmt.Errorf("rule %s: invalid window %q", rule.ID, rule.Window)
+			}
+		case ruleDenyList:
+			if len(rule.Addresses) == 0 {
+				return fmt.Errorf("rule %s: addresses cannot be empty", rule.ID)
+			}
+			rule.denied = make(map[string]bool, len(rule.Addresses))
+			for _, address := range rule.Addresses {
+				rule.denied[address] = true
+			}
+		default:
+			return fmt.Errorf("rule %s: unknown kind %q", rule.ID, rule.Kind)
+		}
+	}
+
+	policyRules = rules
+	log.Info("Policy loaded", "path", path, "rules", len(rules))
+	return nil
+}
+
+// checkPolicy evaluates every rule against m and returns the first
+// violation. Callers must hold bc.mutex.
+func (bc *Blockchain) checkPolicy(m mutation, now time.Time) error {
+	for i := range policyRules {
+		rule := &policyRules[i]
+		message := ""
+		switch rule.Kind {
+		case ruleDenyList:
+			switch {
+			case m.actor != "" && rule.denied[m.actor]:
+				message = "actor address is denied"
+			case m.target != "" && rule.denied[m.target]:
+				message = "target address is denied"
+			}
+		case ruleMaxLive:
+			if m.action == "mint" && bc.ownedNFTs(m.target) >= rule.Max {
+				message = fmt.Sprintf("owner already holds %d live NFTs", rule.Max)
+			}
+		case ruleMintRate:
+			if m.action == "mint" && bc.recentMints(m.target, now.Add(-rule.window)) >= rule.Max {
+				message = fmt.Sprintf("owner was minted %d NFTs within %s", rule.Max, rule.window)
+			}
+		}
+		if message == "" {
+			continue
+		}
+
+		if !m.dryRun {
+			policyViolations.Inc(rule.ID)
+			bc.emitEvent("policy.violation", m.nftId, map[string]string{
+				"rule_id": rule.ID, "action": m.action, "actor": m.actor, "target": m.target,
+			})
+		}
+		return &PolicyViolation{RuleID: rule.ID, Message: message}
+	}
+	return nil
+}
+
+// ownedNFTs counts the NFTs address holds. Callers must hold bc.mutex.
+func (bc *Blockchain) ownedNFTs(address string) int {
+	count := 0
+	for _, owner := range bc.NFTs {
+		if owner == address {
+			count++
+		}
+	}
+	return count
+}
+
+// recentMints counts the mints to owner after since. Callers must hold
+// bc.mutex.
+func (bc *Blockchain) recentMints(owner string, since time.Time) int {
+	count := 0
+	for _, t := range bc.mints[owner] {
+		if t.After(since) {
+			count++
+		}
+	}
+	return count
+}
+
+// recordMint remembers a mint for mint_rate rules, forgetting mints older
+// than the longest window. Callers must hold bc.mutex for writing.
+func (bc *Blockchain) recordMint(owner string, now time.Time) {
+	var longest time.Duration
+	for _, rule := range policyRules {
+		if rule.Kind == ruleMintRate && rule.window > longest {
+			longest = rule.window
+		}
+	}
+	if longest == 0 {
+		return
+	}
+
+	kept := bc.mints[owner][:0]
+	for _, t := range bc.mints[owner] {
+		if now.Sub(t) < longest {
+			kept = append(kept, t)
+		}
+	}
+	bc.mints[owner] = append(kept, now)
+}
+
+// PolicyHandler lists the loaded policy rules.
+func PolicyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rules := policyRules
+	if rules == nil {
+		rules = []PolicyRule{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// asPolicyViolation reports whether err is a policy violation.
+func asPolicyViolation(err error) (*PolicyViolation, bool) {
+	var violation *PolicyViolation
+	ok := errors.As(err, &violation)
+	return violation, ok
+}
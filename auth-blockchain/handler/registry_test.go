@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"testing"
+)
+
+// TestAuthMintBindsRegisteredUser checks that the auth ledger only mints to
+// registered users and binds their registration to every AuthNFT.
+func TestAuthMintBindsRegisteredUser(t *testing.T) {
+	bc := testLedger(t)
+	prevID := ledgerID
+	t.Cleanup(func() { ledgerID = prevID })
+	ledgerID = "auth"
+	_, owner := testWallet(t, bc)
+
+	if _, err := bc.CreateNFT(owner, "auth-unregistered", nil, nil, "", nil); err == nil {
+		t.Error("minted an AuthNFT to an unregistered user")
+	}
+
+	if err := bc.RegisterUser(owner, "alice", "user"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bc.CreateNFT(owner, "auth-filled", nil, nil, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	details, err := bc.TokenDetails("auth-filled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TokenDetails{Username: "alice", UserType: "user", TokenType: "login", Active: true}
+	if details != want {
+		t.Errorf("details %+v, want %+v", details, want)
+	}
+
+	mismatched := &TokenDetails{Username: "alice", UserType: "admin", TokenType: "login"}
+	if _, err := bc.CreateNFT(owner, "auth-mismatched", nil, mismatched, "", nil); err == nil {
+		t.Error("minted with details that do not match the registration")
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestSnapshotImportCarriesState checks that an imported snapshot starts
+// session clocks only for live NFTs and keeps approvals and proposals.
+func TestSnapshotImportCarriesState(t *testing.T) {
+	src := testLedger(t)
+	key, owner := testWallet(t, src)
+	_, operator := testWallet(t, src)
+	ids := testMint(t, src, owner, "snap", 3)
+	transferred, burned, live := ids[0], ids[1], ids[2]
+
+	if _, err := src.TransferNFT(owner, transferred, testSign(t, key, src.HostWallet+transferred)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.BurnNFT(owner, burned, testSign(t, key, burned+"burn")); err != nil {
+		t.Fatal(err)
+	}
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	approval, err := src.Approve(Approval{
+		Owner:     owner,
+		Operator:  operator,
+		NFTId:     live,
+		ExpiresAt: expiresAt,
+		MaxUses:   1,
+		Signature: testSign(t, key, ApprovalMessage(operator, live, "", expiresAt, 1)),
+	}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.mutex.Lock()
+	src.proposals["stuck"] = &Proposal{ID: "stuck", Status: proposalExecuting, ExpiresAt: expiresAt}
+	src.mutex.Unlock()
+	if _, err := src.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	if _, err := src.ExportSnapshot(&archive); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := NewBlockchain()
+	dst.CreateGenesisBlock()
+	if _, err := dst.initHostKey(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.ImportSnapshot(&archive, []string{src.HostWallet}); err != nil {
+		t.Fatal(err)
+	}
+
+	dst.mutex.RLock()
+	defer dst.mutex.RUnlock()
+	for _, id := range []string{transferred, burned} {
+		if _, ok := dst.activity[id]; ok {
+			t.Errorf("%s got a session clock after import", id)
+		}
+	}
+	if _, ok := dst.activity[live]; !ok {
+		t.Errorf("%s has no session clock after import", live)
+	}
+	if !dst.burned[burned] {
+		t.Errorf("%s is not burned after import", burned)
+	}
+	if a := dst.approvals[approval.ID]; a == nil || a.Operator != operator {
+		t.Errorf("approval %s lost in import", approval.ID)
+	}
+	if p := dst.proposals["stuck"]; p == nil || p.Status != proposalFailed {
+		t.Errorf("executing proposal imported as %+v, want failed", p)
+	}
+}
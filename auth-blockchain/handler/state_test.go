@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// testLedger makes a fresh default ledger with a host key and a cheap proof
+// of work, restoring the previous ledger when the test ends.
+func testLedger(tb testing.TB) *Blockchain {
+	tb.Helper()
+	prevLedger, prevDifficulty := blockchain, powDifficulty
+	tb.Cleanup(func() { blockchain, powDifficulty = prevLedger, prevDifficulty })
+
+	powDifficulty = 2
+	bc := NewBlockchain()
+	bc.CreateGenesisBlock()
+	if _, err := bc.initHostKey(""); err != nil {
+		tb.Fatal(err)
+	}
+	blockchain = bc
+	return bc
+}
+
+// testWallet registers a new wallet on bc.
+func testWallet(tb testing.TB, bc *Blockchain) (*ecdsa.PrivateKey, string) {
+	tb.Helper()
+	key, address, err := GenerateWallet()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := bc.registerWallet(address, &key.PublicKey); err != nil {
+		tb.Fatal(err)
+	}
+	return key, address
+}
+
+// testSign signs data the way wallets do.
+func testSign(tb testing.TB, key *ecdsa.PrivateKey, data string) string {
+	tb.Helper()
+	hash := sha256.Sum256([]byte(data))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return hex.EncodeToString(sig)
+}
+
+// testMint mints n NFTs to owner and returns their IDs.
+func testMint(tb testing.TB, bc *Blockchain, owner, prefix string, n int) []string {
+	tb.Helper()
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%s-%d", prefix, i)
+		if _, err := bc.CreateNFT(owner, ids[i], nil, nil, "", nil); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return ids
+}
+
+// validate calls ValidateNFTOwnerHandler and reports whether address owns
+// nftId.
+func validate(tb testing.TB, nftId, address string) bool {
+	body, _ := json.Marshal(map[string]string{"nft_id": nftId, "address": address})
+	w := httptest.NewRecorder()
+	ValidateNFTOwnerHandler(w, httptest.NewRequest(http.MethodPost, "/authnft/validate", bytes.NewReader(body)))
+	var resp struct {
+		Valid bool   `json:"valid"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		tb.Errorf("validate %s: %v", nftId, err)
+	}
+	return resp.Valid
+}
+
+// ownerOf calls GetNFTOwnerHandler.
+func ownerOf(tb testing.TB, nftId string) string {
+	body, _ := json.Marshal(map[string]string{"nft_id": nftId})
+	w := httptest.NewRecorder()
+	GetNFTOwnerHandler(w, httptest.NewRequest(http.MethodPost, "/authnft/owner", bytes.NewReader(body)))
+	var resp struct {
+		Owner string `json:"owner"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		tb.Errorf("owner %s: %v", nftId, err)
+	}
+	return resp.Owner
+}
+
+// TestConcurrentValidationAndWrites validates and looks up owners while
+// mints, transfers and mining run, and checks that readers never see a
+// torn state and that every write lands in a valid chain. Run with -race.
+func TestConcurrentValidationAndWrites(t *testing.T) {
+	bc := testLedger(t)
+	key, owner := testWallet(t, bc)
+	stable := testMint(t, bc, owner, "stable", 20)
+	moving := testMint(t, bc, owner, "moving", 20)
+	if _, err := bc.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	signatures := make([]string, len(moving))
+	for i, id := range moving {
+		signatures[i] = testSign(t, key, bc.HostWallet+id)
+	}
+
+	var (
+		writers sync.WaitGroup
+		readers sync.WaitGroup
+		done    atomic.Bool
+		checks  atomic.Int64
+	)
+
+	writers.Add(3)
+	go func() {
+		defer writers.Done()
+		testMint(t, bc, owner, "fresh", 50)
+	}()
+	go func() {
+		defer writers.Done()
+		for i, id := range moving {
+			if _, err := bc.TransferNFT(owner, id, signatures[i]); err != nil {
+				t.Errorf("transfer %s: %v", id, err)
+			}
+		}
+	}()
+	go func() {
+		defer writers.Done()
+		for i := 0; i < 10; i++ {
+			if _, err := bc.MineBlock(); err != nil {
+				t.Errorf("mine: %v", err)
+			}
+		}
+	}()
+
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func(r int) {
+			defer readers.Done()
+			// Every reader checks at least once, however fast the writers are.
+			for i := r; ; i++ {
+				id := stable[i%len(stable)]
+				if !validate(t, id, owner) {
+					t.Errorf("%s stopped validating for its owner", id)
+					return
+				}
+				if got := ownerOf(t, id); got != owner {
+					t.Errorf("owner of %s = %q, want %q", id, got, owner)
+					return
+				}
+				if got := ownerOf(t, moving[i%len(moving)]); got != owner && got != bc.HostWallet {
+					t.Errorf("owner of %s = %q during transfer", moving[i%len(moving)], got)
+					return
+				}
+				bc.RecentBlocks(5)
+				checks.Add(1)
+				if done.Load() {
+					return
+				}
+			}
+		}(r)
+	}
+
+	writers.Wait()
+	done.Store(true)
+	readers.Wait()
+
+	if _, err := bc.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if checks.Load() == 0 {
+		t.Fatal("readers made no checks")
+	}
+	for _, id := range moving {
+		if got := ownerOf(t, id); got != bc.HostWallet {
+			t.Errorf("owner of %s = %q, want the host wallet", id, got)
+		}
+		if validate(t, id, owner) {
+			t.Errorf("%s still validates for its old owner", id)
+		}
+	}
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	if len(bc.CurrentTransactions) != 0 {
+		t.Errorf("%d transactions left unmined", len(bc.CurrentTransactions))
+	}
+	if err := verifyChain(bc.Chain); err != nil {
+		t.Errorf("chain does not verify: %v", err)
+	}
+	if got, want := len(bc.view.Load().blocks), len(bc.Chain); got != want {
+		t.Errorf("chain view has %d blocks, chain has %d", got, want)
+	}
+}
+
+// TestMineBlockKeepsLateTransactions checks that transactions added while a
+// block is being mined wait for the next block instead of being lost.
+func TestMineBlockKeepsLateTransactions(t *testing.T) {
+	bc := testLedger(t)
+	_, owner := testWallet(t, bc)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		testMint(t, bc, owner, "late", 100)
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 5; i++ {
+			if _, err := bc.MineBlock(); err != nil {
+				t.Errorf("mine: %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+	if _, err := bc.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	sealed := 0
+	for _, block := range bc.Chain {
+		sealed += len(block.Transactions)
+	}
+	if sealed != 100 {
+		t.Errorf("%d mints sealed, want 100", sealed)
+	}
+}
+
+// benchmarkValidate measures validation throughput, with the miner sealing
+// a stream of mints in the background when mining is set.
+func benchmarkValidate(b *testing.B, mining bool) {
+	bc := testLedger(b)
+	_, owner := testWallet(b, bc)
+	ids := testMint(b, bc, owner, "bench", 100)
+	if _, err := bc.MineBlock(); err != nil {
+		b.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	var background sync.WaitGroup
+	if mining {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				bc.CreateNFT(owner, fmt.Sprintf("load-%d", i), nil, nil, "", nil)
+				if i%10 == 0 {
+					bc.MineBlock()
+				}
+			}
+		}()
+	}
+
+	var next atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			id := ids[next.Add(1)%int64(len(ids))]
+			if !validate(b, id, owner) {
+				b.Errorf("%s did not validate", id)
+				return
+			}
+		}
+	})
+	b.StopTimer()
+	close(done)
+	background.Wait()
+}
+
+func BenchmarkValidate(b *testing.B)            { benchmarkValidate(b, false) }
+func BenchmarkValidateWhileMining(b *testing.B) { benchmarkValidate(b, true) }
@@ -1,48 +1,179 @@
 package main
 
 import (
+	"blockchain-api/explorer"
 	"blockchain-api/handler"
 	"blockchain-api/logger"
+	"blockchain-api/metrics"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func startServer(port string, log logger.Logger) {
-	// Generate host wallet
-	_, hostWalletAddress, err := handler.GenerateWallet()
+	// Load or create the host wallet
+	hostWalletAddress, err := handler.InitHostWallet(os.Getenv("HOST_KEY_FILE"))
 	if err != nil {
-		log.Error("Failed to generate host wallet", err)
+		log.Error("Failed to initialize host wallet", "error", err)
 		os.Exit(1)
 	}
 
-	// Assign host wallet to blockchain
-	blockchain := handler.NewBlockchain()
-	blockchain.HostWallet = hostWalletAddress
+	log.Info("Host wallet ready", "address", hostWalletAddress)
 
-	log.Info("Host wallet created", "address", hostWalletAddress)
+	// Shared genesis block, so nodes of one chain agree from block 1
+	if path := os.Getenv("GENESIS_FILE"); path != "" {
+		genesisHash, err := handler.LoadGenesis(path)
+		if err != nil {
+			log.Error("Failed to load genesis", "path", path, "error", err)
+			os.Exit(1)
+		}
+		log.Info("Genesis loaded", "path", path, "genesis_hash", genesisHash)
+	}
+
+	// Create tenant namespaces, each with its own chain and host wallet
+	if path := os.Getenv("TENANTS_FILE"); path != "" {
+		if err := handler.LoadTenants(path); err != nil {
+			log.Error("Failed to load tenants", "path", path, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Seed the ledger from a snapshot before serving any request
+	if path := os.Getenv("SNAPSHOT_IMPORT"); path != "" {
+		if err := handler.ImportSnapshotFile(path); err != nil {
+			log.Error("Failed to import snapshot", "path", path, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Token classes with their own transfer rules, metadata and TTL
+	if path := os.Getenv("TOKEN_CLASSES_FILE"); path != "" {
+		if err := handler.LoadTokenClasses(path); err != nil {
+			log.Error("Failed to load token classes", "path", path, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// m-of-n admin wallet for privileged operations
+	if path := os.Getenv("MULTISIG_FILE"); path != "" {
+		if err := handler.LoadMultisig(path); err != nil {
+			log.Error("Failed to load multisig admin wallet", "path", path, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Rules checked before every ledger mutation
+	if path := os.Getenv("POLICY_FILE"); path != "" {
+		if err := handler.LoadPolicy(path); err != nil {
+			log.Error("Failed to load policy", "path", path, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Move old block bodies to the archive once the chain passes PRUNE_KEEP_BLOCKS
+	if keep, _ := strconv.Atoi(os.Getenv("PRUNE_KEEP_BLOCKS")); keep > 0 {
+		archiveDir := os.Getenv("ARCHIVE_DIR")
+		if archiveDir == "" {
+			archiveDir = "archive"
+		}
+		if err := handler.EnablePruning(archiveDir, keep); err != nil {
+			log.Error("Failed to enable pruning", "dir", archiveDir, "error", err)
+			os.Exit(1)
+		}
+		log.Info("Block pruning enabled", "keep_blocks", keep, "archive_dir", archiveDir)
+	}
+
+	// Seal pending transactions into blocks
+	handler.StartMiner(envSeconds("MINE_INTERVAL_SECONDS", 10))
+
+	// Anchor each ledger's tip on the peer ledger and record the peer's anchors
+	if hosts := os.Getenv("ANCHOR_PEER_WALLETS"); hosts != "" {
+		anchoring := handler.AnchorConfig{
+			PeerURL:   os.Getenv("ANCHOR_PEER_URL"),
+			PeerHosts: strings.Split(hosts, ","),
+			Interval:  envSeconds("ANCHOR_INTERVAL_SECONDS", 60),
+		}
+		if err := handler.StartAnchoring(anchoring); err != nil {
+			log.Error("Failed to start anchoring", "error", err)
+			os.Exit(1)
+		}
+		log.Info("Cross-ledger anchoring enabled", "peer_url", anchoring.PeerURL, "interval", anchoring.Interval)
+	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", handler.RootHandler)
-	mux.HandleFunc("/authnft/create", handler.CreateNFTHandler)
-	mux.HandleFunc("/authnft/transfer", handler.TransferNFTHandler)
-	mux.HandleFunc("/authnft/owner", handler.GetNFTOwnerHandler)
-	mux.HandleFunc("/authnft/burn", handler.BurnNFTHandler)
-	mux.HandleFunc("/authnft/validate", handler.ValidateNFTOwnerHandler)
-	mux.HandleFunc("/authwallet/new", handler.GenerateWalletHandler)
-	mux.HandleFunc("/authwallet/pubaddrval", handler.ValidateAddressHandler)
-	mux.HandleFunc("/verify/signature", handler.SignatureValidationHandler)
+	handle := func(pattern string, h http.HandlerFunc) {
+		mux.HandleFunc(pattern, metrics.InstrumentHandler(pattern, h))
+	}
+	handle("/", handler.RootHandler)
+	handle("/authnft/create", handler.CreateNFTHandler)
+	handle("/authnft/transfer", handler.TransferNFTHandler)
+	handle("/authnft/owner", handler.GetNFTOwnerHandler)
+	handle("/authnft/burn", handler.BurnNFTHandler)
+	handle("/authnft/validate", handler.ValidateNFTOwnerHandler)
+	handle("/authnft/history", handler.NFTHistoryHandler)
+	handle("/authnft/burnproof", handler.BurnProofHandler)
+	handle("/authnft/challenge", handler.ChallengeHandler)
+	handle("/authnft/prove", handler.ProveHandler)
+	handle("/authnft/approve", handler.ApproveHandler)
+	handle("/authnft/approve/revoke", handler.RevokeApprovalHandler)
+	handle("/authnft/approvals", handler.ApprovalsHandler)
+	handle("/authnft/operator", handler.OperatorHandler)
+	handle("/authnft/class", handler.NFTClassHandler)
+	handle("/classes", handler.ClassesHandler)
+	handle("/block", handler.BlockHandler)
+	handle("/genesis", handler.GenesisHandler)
+	handle("/anchor/submit", handler.AnchorSubmitHandler)
+	handle("/anchor/list", handler.AnchorListHandler)
+	handle("/anchor/hashes", handler.AnchorHashesHandler)
+	handle("/anchor/status", handler.AnchorStatusHandler)
+	handle("/state/proof", handler.StateProofHandler)
+	handle("/tx/", handler.TxHandler)
+	handle("/authnft/details", handler.TokenDetailsHandler)
+	handle("/authuser", handler.GetUserHandler)
+	handle("/authuser/register", handler.RequireAdmin(handler.RegisterUserHandler))
+	handle("/authwallet/new", handler.GenerateWalletHandler)
+	handle("/authwallet/pubaddrval", handler.ValidateAddressHandler)
+	handle("/verify/signature", handler.SignatureValidationHandler)
+	handle("/admin/snapshot", handler.RequireAdmin(handler.SnapshotHandler))
+	handle("/admin/policy", handler.RequireAdmin(handler.PolicyHandler))
+	handle("/admin/freeze", handler.RequireAdmin(handler.FreezeHandler))
+	handle("/admin/unfreeze", handler.RequireAdmin(handler.UnfreezeHandler))
+	handle("/admin/compliance", handler.RequireAdmin(handler.ComplianceHandler))
+	handle("/multisig/propose", handler.ProposeHandler)
+	handle("/multisig/proposals", handler.ProposalsHandler)
+	handle("/multisig/approve", handler.ApproveProposalHandler)
+	handle("/multisig/expire", handler.ExpireProposalHandler)
+	handle("/explorer", explorer.Handler(explorer.Config{Ledger: "auth", NFTPrefix: "/authnft"}))
+	handle("/explorer/api/blocks", handler.RecentBlocksHandler)
+	handle("/explorer/api/mempool", handler.MempoolHandler)
+	handle("/explorer/api/wallet", handler.WalletNFTsHandler)
+	handle("/events", handler.EventsHandler)
+	handler.RegisterTx(handle)
+	handler.RegisterV1(handle, "/v1/authnft")
+	mux.Handle("/metrics", metrics.Handler())
 
 	go func() {
 		fmt.Printf("Server running on port %s\n", port)
 		log.Info(fmt.Sprintf("auth blockchain Server running on port %s", port))
-		if err := http.ListenAndServe(":"+port, mux); err != nil {
+		if err := http.ListenAndServe(":"+port, handler.WithTenant(mux)); err != nil {
 			log.Error(fmt.Sprintf("Failed to start server on port %s: %v", port, err))
 			os.Exit(1)
 		}
 	}()
 }
 
+// envSeconds reads a duration in whole seconds from the environment.
+func envSeconds(name string, def int) time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || seconds < 0 {
+		seconds = def
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 func main() {
 	config := logger.NewConfigFromEnv()
 	log, err := logger.NewLogger(config)
@@ -51,6 +182,8 @@ func main() {
 		os.Exit(1)
 	}
 	defer log.Sync()
+	handler.SetLogger(log)
+	handler.SetLedgerID("auth")
 
 	// Start two server instances on different ports
 	startServer("18080", log)
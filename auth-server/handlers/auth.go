@@ -7,12 +7,10 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
-	"os"
 
 	//"time"
 
 	"auth-server/database"
-	"auth-server/logger"
 
 	//"auth-server/logger"
 	"auth-server/models"
@@ -20,16 +18,6 @@ import (
 )
 
 func Login(w http.ResponseWriter, r *http.Request) {
-	config := logger.NewConfigFromEnv()
-
-	// Initialize logger
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -43,6 +31,7 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	//fmt.Println(creds) // Corrected to use fmt.Println instead of fmt.println
 	// No need to check if creds is nil as structs cannot be nil as structs cannot be nil
 	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		loginAttempts.Inc("bad_request")
 		fmt.Println("Invalid request payload")
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
@@ -51,13 +40,14 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("Received credentials: %+v\n", creds)
 
 	var user models.User
-	err = database.DB.QueryRow(context.Background(),
+	err := database.DB.QueryRow(context.Background(),
 		"SELECT id, username, email, password FROM users WHERE username = $1",
 		creds.Username,
 	).Scan(&user.ID, &user.Username, &user.Email, &user.Password)
 	//fmt.Println("User details fetched from database:", user)
 	if err != nil {
 		//log.Warn("User not found or error occurred for username: %s, error: %v", creds.Username, err)
+		loginAttempts.Inc("unknown_user")
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
@@ -66,11 +56,13 @@ func Login(w http.ResponseWriter, r *http.Request) {
 
 	if !utils.CheckPasswordHash(creds.Password, user.Password) {
 		//log.Warn("Invalid password for user: %s", creds.Username)
+		loginAttempts.Inc("bad_password")
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
 
 	fmt.Printf("User authenticated: %s\n", creds.Username)
+	loginAttempts.Inc("success")
 
 	// Generate secure OTP
 	// otp, err := generateSecureOTP()
@@ -145,6 +137,7 @@ func VerifyOTP(w http.ResponseWriter, r *http.Request) {
 	).Scan(&otp.UserID, &otp.Code, &otp.ExpiresAt)
 
 	if err != nil {
+		otpVerifications.Inc("rejected")
 		http.Error(w, "Invalid or expired OTP", http.StatusUnauthorized)
 		return
 	}
@@ -155,5 +148,6 @@ func VerifyOTP(w http.ResponseWriter, r *http.Request) {
 		otp.UserID,
 	)
 
+	otpVerifications.Inc("verified")
 	json.NewEncoder(w).Encode(map[string]bool{"verified": true})
 }
@@ -9,21 +9,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
 )
 
-var log logger.Logger
+// log is the service logger. main injects it with SetLogger at startup.
+var log = logger.NewNop()
 
-
-//init logger
-func init() {
-	config := logger.NewConfigFromEnv()
-	var logerr error
-	log, logerr = logger.NewLogger(config)
-	if logerr != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", logerr)
-		os.Exit(1)
-	}
+// SetLogger sets the logger used by the handlers package.
+func SetLogger(l logger.Logger) {
+	log = l
 }
 
 
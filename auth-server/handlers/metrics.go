@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"auth-server/metrics"
+)
+
+// Auth server metrics exposed on /metrics.
+var (
+	loginAttempts = metrics.NewCounterVec("auth_login_attempts_total",
+		"Password logins, by outcome.", "outcome")
+	otpVerifications = metrics.NewCounterVec("auth_otp_verifications_total",
+		"OTP verifications, by outcome.", "outcome")
+)
@@ -3,7 +3,9 @@ package logger
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -24,14 +26,33 @@ type ZapLogger struct {
 }
 
 type Config struct {
-	Level  string 
-	Format string 
+	Level      string
+	Format     string
+	BaseDir    string
+	RotateTime time.Duration
+	MaxSize    int64
+	MaxAge     time.Duration
+	MaxBackups int
+	Compress   bool
 }
 
 func NewLogger(config Config) (Logger, error) {
 	logLevel := getZapLevel(config.Level)
 	encoderConfig := getEncoderConfig(config.Format)
 
+	if config.BaseDir != "" {
+		if err := os.MkdirAll(config.BaseDir, os.ModePerm); err != nil {
+			return nil, fmt.Errorf("failed to create base directory: %w", err)
+		}
+		// The rotating writer also echoes every entry to the console.
+		encoder := zapcore.NewConsoleEncoder(encoderConfig)
+		if config.Format == "json" {
+			encoder = zapcore.NewJSONEncoder(encoderConfig)
+		}
+		core := zapcore.NewCore(encoder, zapcore.AddSync(newRotatingFileWriter(config)), logLevel)
+		return &ZapLogger{delegate: zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)).Sugar()}, nil
+	}
+
 	zapConfig := zap.Config{
 		Level:             zap.NewAtomicLevelAt(logLevel),
 		Development:       false,
@@ -54,6 +75,12 @@ func NewLogger(config Config) (Logger, error) {
 	return &ZapLogger{delegate: zapLogger.Sugar()}, nil
 }
 
+// NewNop returns a Logger that discards everything. Packages use it until
+// the service injects the real logger at startup.
+func NewNop() Logger {
+	return &ZapLogger{delegate: zap.NewNop().Sugar()}
+}
+
 func NewConfigFromEnv() Config {
 	level := os.Getenv("LOG_LEVEL")
 	if level == "" {
@@ -66,9 +93,22 @@ func NewConfigFromEnv() Config {
 	}
 
 	return Config{
-		Level:  level,
-		Format: format,
+		Level:      level,
+		Format:     format,
+		BaseDir:    os.Getenv("BASE_DIR"),
+		RotateTime: time.Duration(envInt("LOG_ROTATE_MINUTES", 10)) * time.Minute,
+		MaxSize:    int64(envInt("LOG_MAX_SIZE_MB", 100)) << 20,
+		MaxAge:     time.Duration(envInt("LOG_MAX_AGE_DAYS", 0)) * 24 * time.Hour,
+		MaxBackups: envInt("LOG_MAX_BACKUPS", 0),
+		Compress:   os.Getenv("LOG_COMPRESS") != "false",
+	}
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v >= 0 {
+		return v
 	}
+	return def
 }
 
 func getZapLevel(level string) zapcore.Level {
@@ -10,11 +10,28 @@ import (
 	
 	"auth-server/database"
 	"auth-server/handlers"
+	"auth-server/logger"
+	"auth-server/metrics"
+	"auth-server/utils"
 	
 	"github.com/gorilla/mux"
 )
 
 func main() {
+	// Load .env first: the logger and database read their settings from it.
+	err := godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	appLogger, err := logger.NewLogger(logger.NewConfigFromEnv())
+	if err != nil {
+		log.Fatal("Failed to initialize logger:", err)
+	}
+	defer appLogger.Sync()
+	handlers.SetLogger(appLogger)
+	utils.SetLogger(appLogger)
+
 	if err := database.Connect(); err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
@@ -24,6 +41,8 @@ func main() {
 	}
 
 	r := mux.NewRouter()
+	r.Use(metrics.Middleware)
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
 	r.HandleFunc("/login", handlers.Login).Methods("POST")
 	r.HandleFunc("/verify-otp", handlers.VerifyOTP).Methods("POST")
 	r.HandleFunc("/getalluser", handlers.GetAllUsers).Methods("GET")
@@ -35,10 +54,6 @@ func main() {
 	r.HandleFunc("/getreqpubaddr", handlers.GetReqPubAddr).Methods("POST")
 	
 	// Add user CRUD routes here
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,351 @@
+// Package metrics provides counters, gauges and histograms exposed over HTTP
+// in the Prometheus text exposition format.
+//
+// Each service module carries its own copy of this file, since the modules
+// do not share a dependency. Keep the copies identical; router-specific
+// middleware goes in a separate file, such as gin.go or mux.go.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets are the default histogram buckets, in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []collector
+)
+
+func register(c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, c)
+}
+
+// series holds the label values of one time series of a vector.
+type series struct {
+	labelValues []string
+}
+
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+func formatLabels(names, values []string, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", n, escapeLabel(values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if len(names) > 0 || i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", extra[i], escapeLabel(extra[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return strings.ReplaceAll(v, `"`, `\"`)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func checkLabels(metric string, names, values []string) {
+	if len(names) != len(values) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", metric, len(names), len(values)))
+	}
+}
+
+// valueVec is the shared storage for counters and gauges.
+type valueVec struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*series
+	values map[string]float64
+}
+
+func newValueVec(name, help, kind string, labels []string) *valueVec {
+	return &valueVec{
+		metricName: name,
+		help:       help,
+		kind:       kind,
+		labels:     labels,
+		series:     make(map[string]*series),
+		values:     make(map[string]float64),
+	}
+}
+
+func (v *valueVec) name() string { return v.metricName }
+
+func (v *valueVec) update(labelValues []string, fn func(float64) float64) {
+	checkLabels(v.metricName, v.labels, labelValues)
+	key := seriesKey(labelValues)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.series[key]; !ok {
+		v.series[key] = &series{labelValues: append([]string(nil), labelValues...)}
+	}
+	v.values[key] = fn(v.values[key])
+}
+
+func (v *valueVec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.metricName, v.help, v.metricName, v.kind)
+	if len(v.labels) == 0 && len(v.values) == 0 {
+		fmt.Fprintf(w, "%s 0\n", v.metricName)
+		return
+	}
+	for _, key := range sortedKeys(v.values) {
+		fmt.Fprintf(w, "%s%s %s\n", v.metricName, formatLabels(v.labels, v.series[key].labelValues), formatFloat(v.values[key]))
+	}
+}
+
+// CounterVec is a monotonically increasing value partitioned by labels.
+type CounterVec struct {
+	vec *valueVec
+}
+
+// NewCounterVec registers a counter with the given label names.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newValueVec(name, help, "counter", labels)}
+	register(c.vec)
+	return c
+}
+
+// Inc adds one to the series identified by labelValues.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta, which must not be negative, to the series identified by labelValues.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.vec.update(labelValues, func(v float64) float64 { return v + delta })
+}
+
+// GaugeVec is a value that can go up and down, partitioned by labels.
+type GaugeVec struct {
+	vec *valueVec
+}
+
+// NewGaugeVec registers a gauge with the given label names.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{vec: newValueVec(name, help, "gauge", labels)}
+	register(g.vec)
+	return g
+}
+
+// Set sets the series identified by labelValues to v.
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.vec.update(labelValues, func(float64) float64 { return v })
+}
+
+// Add adds delta to the series identified by labelValues.
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.vec.update(labelValues, func(v float64) float64 { return v + delta })
+}
+
+// Inc adds one to the series identified by labelValues.
+func (g *GaugeVec) Inc(labelValues ...string) { g.Add(1, labelValues...) }
+
+// Dec subtracts one from the series identified by labelValues.
+func (g *GaugeVec) Dec(labelValues ...string) { g.Add(-1, labelValues...) }
+
+// gaugeFunc is a gauge whose value is read at scrape time.
+type gaugeFunc struct {
+	metricName string
+	help       string
+	fn         func() float64
+}
+
+// NewGaugeFunc registers a gauge whose value is computed by fn on every scrape.
+func NewGaugeFunc(name, help string, fn func() float64) {
+	register(&gaugeFunc{metricName: name, help: help, fn: fn})
+}
+
+func (g *gaugeFunc) name() string { return g.metricName }
+
+func (g *gaugeFunc) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.metricName, g.help, g.metricName, g.metricName, formatFloat(g.fn()))
+}
+
+// HistogramVec samples observations into cumulative buckets, partitioned by labels.
+type HistogramVec struct {
+	metricName string
+	help       string
+	buckets    []float64
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// NewHistogramVec registers a histogram. A nil buckets slice uses DefBuckets.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	h := &HistogramVec{
+		metricName: name,
+		help:       help,
+		buckets:    buckets,
+		labels:     labels,
+		series:     make(map[string]*histogramSeries),
+	}
+	register(h)
+	return h
+}
+
+// Observe records v in the series identified by labelValues.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	checkLabels(h.metricName, h.labels, labelValues)
+	key := seriesKey(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+// ObserveSince records the seconds elapsed since start.
+func (h *HistogramVec) ObserveSince(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+func (h *HistogramVec) name() string { return h.metricName }
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.metricName, h.help, h.metricName)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, formatLabels(h.labels, s.labelValues, "le", formatFloat(upper)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, formatLabels(h.labels, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, formatLabels(h.labels, s.labelValues), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, formatLabels(h.labels, s.labelValues), s.count)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Handler serves every registered metric in the Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registryMu.Lock()
+		collectors := append([]collector(nil), registry...)
+		registryMu.Unlock()
+
+		sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, c := range collectors {
+			c.write(w)
+		}
+	})
+}
+
+var (
+	httpRequests = NewCounterVec("http_requests_total",
+		"HTTP requests handled, by route, method and status code.", "route", "method", "code")
+	httpDuration = NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency, by route and method.", nil, "route", "method")
+)
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// InstrumentHandler records request count, status and latency for route.
+func InstrumentHandler(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		httpDuration.ObserveSince(start, route, r.Method)
+		httpRequests.Inc(route, r.Method, strconv.Itoa(rec.status))
+	}
+}
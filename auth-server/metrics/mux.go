@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Middleware instruments every request that reaches next, labelled by the
+// gorilla/mux route template rather than the raw path, so IDs in the URL do
+// not create new label values. Requests without a route are grouped under
+// the "unmatched" route.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		InstrumentHandler(route, next.ServeHTTP)(w, r)
+	})
+}
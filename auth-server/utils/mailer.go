@@ -2,7 +2,6 @@
 package utils
 
 import (
-	"auth-server/logger"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -25,16 +24,6 @@ type EmailUser struct {
 func SendOTPEmail(email, otp string) error {
 	fmt.Println("Sending OTP email...")
 
-	config := logger.NewConfigFromEnv()
-
-	// Initialize logger
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	mail := Mail{
 		From: EmailUser{
 			Email: "hello@demomailtrap.co", 
@@ -6,23 +6,16 @@ import (
 	"auth-server/logger"
 	"context"
 	"fmt"
-	"os"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-var log logger.Logger
+// log is the service logger. main injects it with SetLogger at startup.
+var log = logger.NewNop()
 
-
-//init logger
-func init() {
-	config := logger.NewConfigFromEnv()
-	var logerr error
-	log, logerr = logger.NewLogger(config)
-	if logerr != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", logerr)
-		os.Exit(1)
-	}
+// SetLogger sets the logger used by the utils package.
+func SetLogger(l logger.Logger) {
+	log = l
 }
 
 
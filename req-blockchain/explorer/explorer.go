@@ -0,0 +1,34 @@
+// Package explorer serves a read-only HTML view of the ledger.
+package explorer
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+)
+
+//go:embed index.html
+var indexHTML string
+
+var page = template.Must(template.New("explorer").Parse(indexHTML))
+
+// Config tells the page which ledger it is showing and where that ledger's
+// NFT routes live.
+type Config struct {
+	Ledger    string // "auth" or "req"
+	NFTPrefix string // e.g. "/authnft"
+}
+
+// Handler serves the explorer page.
+func Handler(config Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := page.Execute(w, config); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
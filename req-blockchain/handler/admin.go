@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// RequireAdmin guards operator endpoints with the bearer token in ADMIN_TOKEN.
+// When ADMIN_TOKEN is unset the admin API is disabled.
+func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if os.Getenv("ADMIN_TOKEN") == "" {
+			http.Error(w, "Admin API disabled", http.StatusForbidden)
+			return
+		}
+		if !isAdmin(r) {
+			log.Warn("Rejected admin request", "path", r.URL.Path, "remote", r.RemoteAddr)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// isAdmin reports whether r carries the bearer token in ADMIN_TOKEN. It is
+// always false when ADMIN_TOKEN is unset.
+func isAdmin(r *http.Request) bool {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		return false
+	}
+	presented := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
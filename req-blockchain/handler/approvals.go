@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Bounds on what an owner can delegate with a single approval.
+const (
+	maxApprovalLifetime = 7 * 24 * time.Hour
+	maxApprovalUses     = 100
+)
+
+// Approval lets an operator transfer or burn an owner's NFTs without the
+// owner's wallet signing each operation. It covers one NFT or every NFT of
+// a class, and lapses at its expiry or after MaxUses operations.
+type Approval struct {
+	ID        string `json:"approval_id"`
+	Owner     string `json:"owner"`
+	Operator  string `json:"operator"`
+	NFTId     string `json:"nft_id,omitempty"`
+	Class     string `json:"class,omitempty"`
+	ExpiresAt int64  `json:"expires_at"` // Unix seconds
+	MaxUses   int    `json:"max_uses"`
+	Uses      int    `json:"uses"`
+	Revoked   bool   `json:"revoked"`
+	Signature string `json:"signature"`
+}
+
+// ApprovalMessage is the data an owner signs to grant an approval.
+func ApprovalMessage(operator, nftId, class string, expiresAt int64, maxUses int) string {
+	return fmt.Sprintf("approve:%s:%s:%s:%s:%d:%d", ledgerID, operator, nftId, class, expiresAt, maxUses)
+}
+
+// RevokeMessage is the data an owner signs to revoke an approval.
+func RevokeMessage(approvalID string) string {
+	return "revoke:" + approvalID
+}
+
+// OperatorMessage is the data an operator signs for one use of an approval.
+// Including the use count makes every signature single-use.
+func OperatorMessage(approvalID, action, nftId string, uses int) string {
+	return fmt.Sprintf("operate:%s:%s:%s:%d", approvalID, action, nftId, uses)
+}
+
+// active reports whether the approval can still be used at now.
+func (a *Approval) active(now time.Time) bool {
+	return !a.Revoked && a.Uses < a.MaxUses && now.Unix() < a.ExpiresAt
+}
+
+// covers reports whether the approval applies to nftId, minted in class.
+func (a *Approval) covers(nftId, class string) bool {
+	if a.NFTId != "" {
+		return a.NFTId == nftId
+	}
+	return a.Class == class
+}
+
+// Approve records an approval signed by its owner. An approval is identified
+// by the hash of its signed message, so the same signed approval cannot be
+// registered twice to reset its use count.
+func (bc *Blockchain) Approve(a Approval, now time.Time) (*Approval, error) {
+	if a.Owner == "" || a.Operator == "" {
+		return nil, errors.New("owner and operator are required")
+	}
+	if (a.NFTId == "") == (a.Class == "") {
+		return nil, errors.New("exactly one of nft_id and class is required")
+	}
+	if a.MaxUses < 1 || a.MaxUses > maxApprovalUses {
+		return nil, fmt.Errorf("max_uses must be between 1 and %d", maxApprovalUses)
+	}
+	expires := time.Unix(a.ExpiresAt, 0)
+	if !expires.After(now) || expires.Sub(now) > maxApprovalLifetime {
+		return nil, fmt.Errorf("expires_at must be in the future and within %s", maxApprovalLifetime)
+	}
+	if _, err := publicKeyFromAddress(a.Operator); err != nil {
+		return nil, fmt.Errorf("invalid operator address: %w", err)
+	}
+
+	message := ApprovalMessage(a.Operator, a.NFTId, a.Class, a.ExpiresAt, a.MaxUses)
+	id := sha256.Sum256([]byte(a.Owner + message))
+	a.ID = hex.EncodeToString(id[:])
+	a.Uses = 0
+	a.Revoked = false
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	if a.NFTId != "" {
+		owner, exists := bc.NFTs[a.NFTId]
+		if !exists {
+			return nil, ErrNFTNotFound
+		}
+		if bc.burned[a.NFTId] {
+			return nil, ErrBurned
+		}
+		if owner != a.Owner {
+			return nil, ErrNotOwner
+		}
+	}
+	if !bc.ValidateSignature(a.Owner, message, a.Signature) {
+		return nil, ErrBadSignature
+	}
+	if _, exists := bc.approvals[a.ID]; exists {
+		return nil, errors.New("approval already registered")
+	}
+	if err := bc.checkPolicy(mutation{action: "approve", actor: a.Owner, target: a.Operator, nftId: a.NFTId}, now); err != nil {
+		return nil, err
+	}
+
+	// Expired approvals no longer need to be remembered to prevent replays.
+	for id, existing := range bc.approvals {
+		if now.Unix() >= existing.ExpiresAt {
+			delete(bc.approvals, id)
+		}
+	}
+	bc.approvals[a.ID] = &a
+	bc.emitEvent("approval.granted", a.NFTId, map[string]string{
+		"approval_id": a.ID, "owner": a.Owner, "operator": a.Operator, "class": a.Class,
+	})
+	result := a
+	return &result, nil
+}
+
+// RevokeApproval cancels an approval with a signature from its owner.
+func (bc *Blockchain) RevokeApproval(approvalID, signature string) error {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	a, exists := bc.approvals[approvalID]
+	if !exists {
+		return errors.New("approval does not exist")
+	}
+	if !bc.ValidateSignature(a.Owner, RevokeMessage(approvalID), signature) {
+		return ErrBadSignature
+	}
+	a.Revoked = true
+	bc.emitEvent("approval.revoked", a.NFTId, map[string]string{"approval_id": a.ID, "owner": a.Owner})
+	return nil
+}
+
+// Approvals returns the approvals granted by owner, newest expiry first.
+func (bc *Blockchain) Approvals(owner string) []Approval {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	result := []Approval{}
+	for _, a := range bc.approvals {
+		if a.Owner == owner {
+			result = append(result, *a)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ExpiresAt > result[j].ExpiresAt })
+	return result
+}
+
+// OperateNFT transfers or burns nftId to the host wallet on behalf of its
+// owner under an approval. action is "transfer" or "burn". Once the
+// approval is found, a rejected operation returns its receipt along with the
+// error.
+func (bc *Blockchain) OperateNFT(approvalID, action, nftId, signature string, now time.Time) (receipt Receipt, err error) {
+	var tx *Transaction
+	defer func() {
+		if err != nil && tx != nil {
+			receipt = bc.rejectTransaction(*tx, err)
+		}
+	}()
+
+	if action != "transfer" && action != "burn" {
+		return Receipt{}, errors.New("action must be transfer or burn")
+	}
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	a, exists := bc.approvals[approvalID]
+	if !exists {
+		return Receipt{}, errors.New("approval does not exist")
+	}
+	tx = &Transaction{
+		Sender:    a.Owner,
+		Recipient: bc.HostWallet,
+		NFTId:     nftId,
+		Signature: signature,
+		Type:      action,
+		Signer:    a.Operator,
+	}
+	if !a.active(now) {
+		return Receipt{}, errors.New("approval is revoked, expired or used up")
+	}
+	if !a.covers(nftId, bc.nftClass(nftId)) {
+		return Receipt{}, errors.New("approval does not cover this NFT")
+	}
+
+	currentOwner, exists := bc.NFTs[nftId]
+	if !exists {
+		return Receipt{}, ErrNFTNotFound
+	}
+	if bc.burned[nftId] {
+		return Receipt{}, ErrBurned
+	}
+	if currentOwner != a.Owner {
+		return Receipt{}, ErrNotOwner
+	}
+	if err := bc.frozen(nftId, a.Owner, a.Operator); err != nil {
+		return Receipt{}, err
+	}
+	if bc.expired(nftId, now) {
+		return Receipt{}, ErrExpired
+	}
+	if err := bc.checkTransferRule(nftId, bc.HostWallet, action == "burn"); err != nil {
+		return Receipt{}, err
+	}
+
+	operatorKey, err := publicKeyFromAddress(a.Operator)
+	if err != nil {
+		return Receipt{}, err
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		validationFailures.Inc("bad_signature")
+		return Receipt{}, ErrBadSignature
+	}
+	hash := sha256.Sum256([]byte(OperatorMessage(approvalID, action, nftId, a.Uses)))
+	if !ecdsa.VerifyASN1(operatorKey, hash[:], sig) {
+		validationFailures.Inc("bad_signature")
+		return Receipt{}, ErrBadSignature
+	}
+	if err := bc.checkPolicy(mutation{action: "operate", actor: a.Operator, target: a.Owner, nftId: nftId}, now); err != nil {
+		return Receipt{}, err
+	}
+
+	a.Uses++
+	bc.NFTs[nftId] = bc.HostWallet
+	delete(bc.activity, nftId)
+	if action == "burn" {
+		bc.markBurned(nftId)
+		nftsBurned.Inc()
+	} else {
+		nftsTransferred.Inc()
+	}
+	receipt = bc.addTransaction(*tx)
+	bc.emitEvent("approval.used", nftId, map[string]string{
+		"approval_id": a.ID, "owner": a.Owner, "operator": a.Operator, "action": action,
+	})
+	log.Info("NFT moved to host wallet by operator", "nft_id", nftId, "action", action, "operator", a.Operator)
+	return receipt, nil
+}
+
+// ApproveHandler registers an owner-signed approval.
+func ApproveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req Approval
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	approval, err := ledgerFor(r).Approve(req, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to register approval", "owner", req.Owner, "operator", req.Operator, "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(approval)
+}
+
+// RevokeApprovalHandler cancels an approval.
+func RevokeApprovalHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ApprovalID string `json:"approval_id"`
+		Signature  string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	if err := ledgerFor(r).RevokeApproval(req.ApprovalID, req.Signature); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to revoke approval", "approval_id", req.ApprovalID, "error", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ApprovalsHandler lists the approvals granted by the owner query parameter.
+func ApprovalsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	owner := r.URL.Query().Get("owner")
+	if owner == "" {
+		http.Error(w, "Missing owner", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ledgerFor(r).Approvals(owner))
+}
+
+// OperatorHandler transfers or burns an NFT on its owner's behalf.
+func OperatorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ApprovalID string `json:"approval_id"`
+		Action     string `json:"action"`
+		NFTId      string `json:"nft_id"`
+		Signature  string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	receipt, err := ledgerFor(r).OperateNFT(req.ApprovalID, req.Action, req.NFTId, req.Signature, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Operator request rejected", "approval_id", req.ApprovalID, "nft_id", req.NFTId, "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"transferred": true, "receipt": receipt})
+}
@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AuthNFT burn links
+//
+// A login burns the user's AuthNFT on the auth ledger, and the ReqNFT minted
+// for the session carries the auth ledger's BurnProof of that burn. The req
+// ledger checks the proof before minting and keeps it in the mint
+// transaction, so every ReqNFT can be traced to the login that authorized
+// it, and each burn authorizes one mint. The req ledger rejects every mint
+// without a proof, and every proof not signed by one of the trusted auth host
+// wallets configured with RequireAuthBurns.
+
+// BurnProof is an auth ledger host's signed statement that it accepted Burn.
+type BurnProof struct {
+	Ledger    string      `json:"ledger"` // Ledger that burned the NFT, "auth"
+	Tenant    string      `json:"tenant,omitempty"`
+	TxHash    string      `json:"tx_hash"` // TxHash of Burn
+	Burn      Transaction `json:"burn"`
+	IssuedAt  int64       `json:"issued_at"` // Unix seconds
+	Host      string      `json:"host"`      // Host wallet that signed the proof
+	Signature string      `json:"signature"` // Over the proof's signing bytes
+}
+
+// authHosts are the auth ledger host wallets whose burn proofs are trusted.
+// While it is empty, no proof is accepted.
+var authHosts []string
+
+// RequireAuthBurns trusts burn proofs signed by hosts and makes every mint
+// carry one.
+func RequireAuthBurns(hosts []string) error {
+	var trusted []string
+	for _, host := range hosts {
+		if host = strings.TrimSpace(host); host == "" {
+			continue
+		}
+		if _, err := publicKeyFromAddress(host); err != nil {
+			return fmt.Errorf("auth host wallet %s: %w", host, err)
+		}
+		trusted = append(trusted, host)
+	}
+	if len(trusted) == 0 {
+		return errors.New("no auth host wallets given")
+	}
+	authHosts = trusted
+	return nil
+}
+
+// signingBytes returns the JSON encoding of p without its signature.
+func (p BurnProof) signingBytes() []byte {
+	p.Signature = ""
+	b, _ := json.Marshal(p)
+	return b
+}
+
+// burnMessage returns the message the owner signed to authorize burn:
+// the NFT ID followed by "burn", or the envelope encoding for an envelope
+// burn.
+func burnMessage(ledger, tenant string, burn Transaction) []byte {
+	if burn.Nonce > 0 {
+		e := TxEnvelope{Type: "burn", Sender: burn.Sender, NFTId: burn.NFTId, Nonce: burn.Nonce}
+		return e.signingBytes(ledger, tenant)
+	}
+	return []byte(burn.NFTId + "burn")
+}
+
+// verifyAddressSignature checks a hex signature over the SHA-256 of data by
+// the key address encodes, whether or not it is registered on this ledger.
+func verifyAddressSignature(address string, data []byte, signature string) bool {
+	pub, err := publicKeyFromAddress(address)
+	if err != nil {
+		return false
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	hash := sha256.Sum256(data)
+	return ecdsa.VerifyASN1(pub, hash[:], sig)
+}
+
+// authBurnError is the error for a missing or invalid burn proof.
+func authBurnError(format string, args ...interface{}) error {
+	return &APIError{http.StatusForbidden, codeAuthBurn, fmt.Sprintf(format, args...)}
+}
+
+// checkAuthBurn checks the burn proof a mint carries. Every req ledger mint
+// must carry one; other ledgers mint without. Callers must hold bc.mutex.
+func (bc *Blockchain) checkAuthBurn(proof *BurnProof) error {
+	if proof == nil {
+		if ledgerID == "req" {
+			return authBurnError("mint requires proof of an AuthNFT burn")
+		}
+		return nil
+	}
+
+	burn := proof.Burn
+	switch {
+	case len(authHosts) == 0:
+		return authBurnError("no trusted auth host wallets are configured")
+	case !slices.Contains(authHosts, proof.Host):
+		return authBurnError("proof host %s is not a trusted auth host", proof.Host)
+	case !verifyAddressSignature(proof.Host, proof.signingBytes(), proof.Signature):
+		return authBurnError("proof signature does not verify")
+	case proof.Ledger != "auth":
+		return authBurnError("proof is from ledger %q, not the auth ledger", proof.Ledger)
+	case proof.Tenant != bc.tenant:
+		return authBurnError("proof is for tenant %q, not %q", proof.Tenant, bc.tenant)
+	case burn.Type != "burn" || burn.Signer != "":
+		return authBurnError("proof is not for a burn signed by the NFT owner")
+	case TxHash(burn) != proof.TxHash:
+		return authBurnError("proof transaction hash does not match the burn")
+	case !verifyAddressSignature(burn.Sender, burnMessage(proof.Ledger, proof.Tenant, burn), burn.Signature):
+		return authBurnError("burn is not signed by its sender")
+	}
+	if nftId, used := bc.authBurns[proof.TxHash]; used {
+		return authBurnError("burn %s already authorized %s", proof.TxHash, nftId)
+	}
+	return nil
+}
+
+// linkAuthBurn records that the mint tx used its burn proof. Callers must
+// hold bc.mutex for writing.
+func (bc *Blockchain) linkAuthBurn(tx Transaction) {
+	if tx.AuthBurn != nil {
+		bc.authBurns[tx.AuthBurn.TxHash] = tx.NFTId
+		bc.origins[tx.NFTId] = tx.AuthBurn
+	}
+}
+
+// BurnProof signs a proof of the owner-signed burn with hash txHash, pending
+// or sealed.
+func (bc *Blockchain) BurnProof(txHash string) (*BurnProof, error) {
+	var burn *Transaction
+	bc.mutex.RLock()
+	for _, tx := range bc.CurrentTransactions {
+		if TxHash(tx) == txHash {
+			burn = &tx
+			break
+		}
+	}
+	sealed, isSealed := bc.txIndex[txHash]
+	bc.mutex.RUnlock()
+
+	if burn == nil && isSealed {
+		block, err := bc.BlockAt(sealed.blockIndex)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range block.Transactions {
+			if TxHash(tx) == txHash {
+				burn = &tx
+				break
+			}
+		}
+	}
+	if burn == nil {
+		return nil, ErrTxNotFound
+	}
+	if burn.Type != "burn" || burn.Signer != "" {
+		return nil, errors.New("transaction is not a burn signed by the NFT owner")
+	}
+
+	proof := &BurnProof{Ledger: ledgerID, Tenant: bc.tenant, TxHash: txHash, Burn: *burn,
+		IssuedAt: time.Now().Unix(), Host: bc.HostWallet}
+	signature, err := bc.hostSign(proof.signingBytes())
+	if err != nil {
+		return nil, err
+	}
+	proof.Signature = signature
+	return proof, nil
+}
+
+// Origin returns the burn proof that authorized the mint of nftId.
+func (bc *Blockchain) Origin(nftId string) (*BurnProof, error) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	if _, exists := bc.NFTs[nftId]; !exists {
+		return nil, ErrNFTNotFound
+	}
+	proof, ok := bc.origins[nftId]
+	if !ok {
+		return nil, errors.New("NFT was minted without an AuthNFT burn")
+	}
+	return proof, nil
+}
+
+// BurnProofHandler returns a signed proof of a burn: POST {tx_hash}.
+func BurnProofHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		TxHash string `json:"tx_hash"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	proof, err := ledgerFor(r).BurnProof(strings.ToLower(req.TxHash))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrTxNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		log.Error("Failed to prove burn", "tx_hash", req.TxHash, "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(proof)
+}
+
+// OriginHandler returns the AuthNFT burn behind an NFT: POST {nft_id}.
+func OriginHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		NFTId string `json:"nft_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	proof, err := ledgerFor(r).Origin(req.NFTId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"nft_id": req.NFTId, "auth_burn": proof})
+}
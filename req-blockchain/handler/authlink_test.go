@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"testing"
+)
+
+// testAuthBurn burns an AuthNFT on a new auth ledger for tenant and returns
+// the host's proof of the burn and the host wallet.
+func testAuthBurn(t *testing.T, tenant string) (*BurnProof, string) {
+	t.Helper()
+	prevID := ledgerID
+	ledgerID = "auth"
+	defer func() { ledgerID = prevID }()
+
+	auth := NewBlockchain()
+	auth.tenant = tenant
+	auth.CreateGenesisBlock()
+	if _, err := auth.initHostKey(""); err != nil {
+		t.Fatal(err)
+	}
+	key, owner := testWallet(t, auth)
+	if err := auth.RegisterUser(owner, "alice", "user"); err != nil {
+		t.Fatal(err)
+	}
+	nftId := testMint(t, auth, owner, "auth", 1)[0]
+	receipt, err := auth.BurnNFT(owner, nftId, testSign(t, key, nftId+"burn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := auth.BurnProof(receipt.TxHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof, auth.HostWallet
+}
+
+// TestReqMintRequiresAuthBurn checks that the req ledger only mints against
+// an unused burn proof signed by a trusted auth host for its own tenant.
+func TestReqMintRequiresAuthBurn(t *testing.T) {
+	proof, host := testAuthBurn(t, "")
+	otherTenant, otherHost := testAuthBurn(t, "a")
+
+	forgerKey, forger, err := GenerateWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := *proof
+	forged.Host = forger
+	forged.Signature = testSign(t, forgerKey, string(forged.signingBytes()))
+
+	tampered := *proof
+	tampered.IssuedAt++
+
+	req := testLedger(t)
+	_, owner := testWallet(t, req)
+	prevID, prevHosts := ledgerID, authHosts
+	t.Cleanup(func() { ledgerID, authHosts = prevID, prevHosts })
+	ledgerID = "req"
+
+	authHosts = nil
+	if _, err := req.CreateNFT(owner, "req-untrusted", proof, nil, "", nil); err == nil {
+		t.Error("minted with no trusted auth hosts configured")
+	}
+	if err := RequireAuthBurns([]string{host, otherHost}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		proof *BurnProof
+		ok    bool
+	}{
+		{"no proof", nil, false},
+		{"forged by an untrusted host", &forged, false},
+		{"altered after signing", &tampered, false},
+		{"wrong tenant", otherTenant, false},
+		{"valid", proof, true},
+		{"replayed", proof, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := req.CreateNFT(owner, "req-"+string(rune('a'+i)), tt.proof, nil, "", nil)
+			if (err == nil) != tt.ok {
+				t.Errorf("mint error %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
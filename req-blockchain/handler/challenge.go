@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// challengeTTL is how long an issued nonce can be used to prove possession.
+const challengeTTL = time.Minute
+
+// challenge is an outstanding nonce for one NFT and address.
+type challenge struct {
+	nftId   string
+	address string
+	expires time.Time
+}
+
+// challengeStore holds a ledger's issued nonces until they are used or
+// expire. Each nonce can be used for a single prove attempt.
+type challengeStore struct {
+	sync.Mutex
+	byNonce map[string]challenge
+}
+
+// ChallengeMessage is the data an owner signs to prove possession of an NFT.
+func ChallengeMessage(nonce, nftId string) string {
+	return nonce + nftId + ledgerID
+}
+
+// issueChallenge creates a nonce for nftId and address and drops expired ones.
+func (bc *Blockchain) issueChallenge(nftId, address string, now time.Time) (string, time.Time, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	nonce := hex.EncodeToString(buf)
+	expires := now.Add(challengeTTL)
+
+	challenges := bc.challenges
+	challenges.Lock()
+	defer challenges.Unlock()
+	for n, c := range challenges.byNonce {
+		if now.After(c.expires) {
+			delete(challenges.byNonce, n)
+		}
+	}
+	challenges.byNonce[nonce] = challenge{nftId: nftId, address: address, expires: expires}
+	return nonce, expires, nil
+}
+
+// takeChallenge removes and returns the challenge for nonce.
+func (bc *Blockchain) takeChallenge(nonce string) (challenge, bool) {
+	challenges := bc.challenges
+	challenges.Lock()
+	defer challenges.Unlock()
+	c, ok := challenges.byNonce[nonce]
+	delete(challenges.byNonce, nonce)
+	return c, ok
+}
+
+// ChallengeHandler issues a short-lived nonce that the owner of an NFT must
+// sign to prove possession.
+func ChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		NFTId   string `json:"nft_id"`
+		Address string `json:"address"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+	if req.NFTId == "" || req.Address == "" {
+		http.Error(w, "Missing nft_id or address in request body", http.StatusBadRequest)
+		return
+	}
+
+	bc := ledgerFor(r)
+	bc.mutex.RLock()
+	_, exists := bc.NFTs[req.NFTId]
+	bc.mutex.RUnlock()
+	if !exists {
+		http.Error(w, "NFT not found", http.StatusNotFound)
+		return
+	}
+
+	nonce, expires, err := bc.issueChallenge(req.NFTId, req.Address, time.Now())
+	if err != nil {
+		log.Error("Failed to generate challenge nonce", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("Challenge issued", "nft_id", req.NFTId, "address", req.Address)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"nonce":      nonce,
+		"ledger":     ledgerID,
+		"message":    ChallengeMessage(nonce, req.NFTId),
+		"expires_at": expires.UTC(),
+	})
+}
+
+// ProveHandler checks a signature over a challenge nonce, the NFT ID and the
+// ledger ID, and reports whether the signer owns the NFT. Like
+// ValidateNFTOwnerHandler it takes an optional min_confirmations, and the
+// response has the same shape.
+func ProveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		NFTId     string `json:"nft_id"`
+		Address   string `json:"address"`
+		Nonce     string `json:"nonce"`
+		Signature string `json:"signature"`
+
+		MinConfirmations int `json:"min_confirmations"` // Optional; 0 accepts pending ownership
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	reject := func(reason, message string) {
+		validationFailures.Inc(reason)
+		log.Error("Possession proof rejected", "nft_id", req.NFTId, "address", req.Address, "reason", message)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"valid": false,
+			"error": message,
+		})
+	}
+
+	bc := ledgerFor(r)
+	c, ok := bc.takeChallenge(req.Nonce)
+	if !ok || time.Now().After(c.expires) {
+		reject("bad_challenge", "Unknown or expired challenge")
+		return
+	}
+	if c.nftId != req.NFTId || c.address != req.Address {
+		reject("bad_challenge", "Challenge was issued for a different NFT or address")
+		return
+	}
+
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	owner, exists := bc.NFTs[req.NFTId]
+	if !exists {
+		reject("nft_not_found", "NFT not found")
+		return
+	}
+	if bc.expired(req.NFTId, time.Now()) {
+		reject("expired", "NFT has expired")
+		return
+	}
+	if !bc.ownedBy(owner, req.Address) {
+		reject("not_owner", "Address does not match the owner")
+		return
+	}
+	if err := bc.frozen(req.NFTId, owner, req.Address); err != nil {
+		reject("frozen", err.Error())
+		return
+	}
+	finality := bc.finality(req.NFTId)
+	if err := finality.confirmed(req.MinConfirmations); err != nil {
+		validationFailures.Inc("unconfirmed")
+		log.Error("Possession proof rejected", "nft_id", req.NFTId, "address", req.Address, "reason", err.Error(),
+			"confirmations", finality.Confirmations, "min_confirmations", req.MinConfirmations)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"valid":         false,
+			"error":         err.Error(),
+			"status":        finality.Status,
+			"block_index":   finality.BlockIndex,
+			"confirmations": finality.Confirmations,
+		})
+		return
+	}
+	if !bc.ValidateSignature(req.Address, ChallengeMessage(req.Nonce, req.NFTId), req.Signature) {
+		// ValidateSignature counts the failure itself.
+		log.Error("Possession proof rejected", "nft_id", req.NFTId, "address", req.Address, "reason", "Invalid signature")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"valid": false,
+			"error": "Invalid signature",
+		})
+		return
+	}
+
+	bc.touchNFT(req.NFTId)
+	log.Info("Possession proved", "nft_id", req.NFTId, "address", req.Address)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"valid":         true,
+		"status":        finality.Status,
+		"block_index":   finality.BlockIndex,
+		"confirmations": finality.Confirmations,
+	})
+}
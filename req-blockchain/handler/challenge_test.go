@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// proveResult is the prove endpoint's response.
+type proveResult struct {
+	Valid         bool   `json:"valid"`
+	Error         string `json:"error"`
+	Status        string `json:"status"`
+	BlockIndex    int    `json:"block_index"`
+	Confirmations int    `json:"confirmations"`
+}
+
+// prove runs the challenge-response flow for nftId and returns the prove
+// endpoint's response. Error is "" if the proof was accepted.
+func prove(t *testing.T, nftId, address string, sign func(string) string, minConfirmations int) proveResult {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"nft_id": nftId, "address": address})
+	w := httptest.NewRecorder()
+	ChallengeHandler(w, httptest.NewRequest(http.MethodPost, "/authnft/challenge", bytes.NewReader(body)))
+	var challenge struct {
+		Nonce   string `json:"nonce"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&challenge); err != nil || challenge.Nonce == "" {
+		t.Fatalf("challenge for %s: %v", nftId, err)
+	}
+
+	body, _ = json.Marshal(map[string]interface{}{
+		"nft_id":            nftId,
+		"address":           address,
+		"nonce":             challenge.Nonce,
+		"signature":         sign(challenge.Message),
+		"min_confirmations": minConfirmations,
+	})
+	w = httptest.NewRecorder()
+	ProveHandler(w, httptest.NewRequest(http.MethodPost, "/authnft/prove", bytes.NewReader(body)))
+	var result proveResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("prove %s: %v", nftId, err)
+	}
+	if !result.Valid && result.Error == "" {
+		t.Fatalf("prove %s rejected without an error", nftId)
+	}
+	return result
+}
+
+func TestProveMinConfirmations(t *testing.T) {
+	bc := testLedger(t)
+	key, owner := testWallet(t, bc)
+	id := testMint(t, bc, owner, "prove", 1)[0]
+	sign := func(message string) string { return testSign(t, key, message) }
+
+	pending := proveResult{Valid: true, Status: "pending"}
+	if got := prove(t, id, owner, sign, 0); got != pending {
+		t.Errorf("pending NFT without min_confirmations: %+v, want %+v", got, pending)
+	}
+	if got := prove(t, id, owner, sign, 1); got.Valid || got.Status != "pending" || got.Confirmations != 0 {
+		t.Errorf("pending NFT with min_confirmations 1: %+v", got)
+	}
+	if _, err := bc.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	sealed := proveResult{Valid: true, Status: "sealed", BlockIndex: 2, Confirmations: 1}
+	if got := prove(t, id, owner, sign, 1); got != sealed {
+		t.Errorf("sealed NFT with min_confirmations 1: %+v, want %+v", got, sealed)
+	}
+	if got := prove(t, id, owner, sign, 5); got.Valid || got.Status != "sealed" || got.BlockIndex != 2 || got.Confirmations != 1 {
+		t.Errorf("NFT with 1 confirmation proved with min_confirmations 5: %+v", got)
+	}
+	if got := prove(t, id, owner, func(string) string { return testSign(t, key, "other") }, 0); got.Valid {
+		t.Error("proved with a signature over the wrong message")
+	}
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// Transfer rules a token class can have.
+const (
+	// TransferHostOnly tokens move only to the host wallet, by transfer or
+	// burn. This is the rule of the ledger's default class.
+	TransferHostOnly = "host_only"
+	// TransferFree tokens can be transferred to any address and burned.
+	TransferFree = "free"
+	// TransferBurnOnly tokens cannot be transferred but their owner can burn
+	// them.
+	TransferBurnOnly = "burn_only"
+	// TransferSoulbound tokens stay with their owner until they expire or
+	// the host retires them.
+	TransferSoulbound = "soulbound"
+)
+
+// TokenClass is a kind of token with its own transfer rule, allowed metadata
+// and lifetime. Classes are loaded from a JSON array in TOKEN_CLASSES_FILE,
+// for example:
+//
+//	[{"name": "admin-override", "transfer": "soulbound", "metadata": ["reason"],
+//	  "ttl": "15m", "admin_mint": true},
+//	 {"name": "device-pairing", "transfer": "free", "metadata": ["device_id"], "ttl": "24h"}]
+//
+// Every ledger also has a default class named after the ledger ("auth" or
+// "req") with the host_only rule, used when a mint names no class.
+type TokenClass struct {
+	Name      string   `json:"name"`
+	Transfer  string   `json:"transfer"`
+	Metadata  []string `json:"metadata,omitempty"`   // Allowed metadata keys
+	TTL       string   `json:"ttl,omitempty"`        // Lifetime from mint; empty for none
+	AdminMint bool     `json:"admin_mint,omitempty"` // Only the admin token can mint
+
+	ttl time.Duration
+}
+
+// NFTClassInfo is the class record bound to an NFT at mint.
+type NFTClassInfo struct {
+	Class     string            `json:"class"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	ExpiresAt int64             `json:"expires_at,omitempty"` // Unix seconds; 0 never expires
+}
+
+// tokenClasses holds the configured classes by name. It is set once at
+// startup by LoadTokenClasses or LoadGenesis and read-only afterwards.
+var tokenClasses = map[string]*TokenClass{}
+
+// LoadTokenClasses reads and validates the classes in the JSON file at path.
+func LoadTokenClasses(path string) error {
+	if genesis != nil && genesis.Classes != nil {
+		return errors.New("token classes are already defined by the genesis file")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read token classes file: %w", err)
+	}
+	var classes []*TokenClass
+	if err := json.Unmarshal(data, &classes); err != nil {
+		return fmt.Errorf("failed to parse token classes file: %w", err)
+	}
+	if err := setTokenClasses(classes); err != nil {
+		return err
+	}
+	log.Info("Token classes loaded", "path", path, "classes", len(classes))
+	return nil
+}
+
+// setTokenClasses validates classes and makes them the configured classes.
+func setTokenClasses(classes []*TokenClass) error {
+	var err error
+	byName := make(map[string]*TokenClass, len(classes))
+	for _, class := range classes {
+		if class.Name == "" {
+			return errors.New("token class has no name")
+		}
+		if _, exists := byName[class.Name]; exists {
+			return fmt.Errorf("token class %s is defined twice", class.Name)
+		}
+		switch class.Transfer {
+		case TransferHostOnly, TransferFree, TransferBurnOnly, TransferSoulbound:
+		default:
+			return fmt.Errorf("token class %s: unknown transfer rule %q", class.Name, class.Transfer)
+		}
+		if class.TTL != "" {
+			class.ttl, err = time.ParseDuration(class.TTL)
+			if err != nil || class.ttl <= 0 {
+				return fmt.Errorf("token class %s: invalid ttl %q", class.Name, class.TTL)
+			}
+		}
+		byName[class.Name] = class
+	}
+
+	tokenClasses = byName
+	return nil
+}
+
+// lookupClass returns the class called name, or the default class for an
+// empty name. The default class can be overridden in configuration.
+func lookupClass(name string) (*TokenClass, error) {
+	if name == "" {
+		name = ledgerID
+	}
+	if class, ok := tokenClasses[name]; ok {
+		return class, nil
+	}
+	if name == ledgerID {
+		return &TokenClass{Name: ledgerID, Transfer: TransferHostOnly}, nil
+	}
+	return nil, fmt.Errorf("unknown token class %q", name)
+}
+
+// TokenClasses returns the configured classes and the default class, sorted
+// by name.
+func TokenClasses() []TokenClass {
+	result := []TokenClass{}
+	if _, ok := tokenClasses[ledgerID]; !ok {
+		class, _ := lookupClass("")
+		result = append(result, *class)
+	}
+	for _, class := range tokenClasses {
+		result = append(result, *class)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// newClassInfo checks a mint's metadata against class and returns the
+// record to bind to the NFT.
+func newClassInfo(class *TokenClass, metadata map[string]string, now time.Time) (*NFTClassInfo, error) {
+	for key := range metadata {
+		allowed := false
+		for _, k := range class.Metadata {
+			allowed = allowed || k == key
+		}
+		if !allowed {
+			return nil, fmt.Errorf("metadata key %q is not allowed for class %s", key, class.Name)
+		}
+	}
+	info := &NFTClassInfo{Class: class.Name, Metadata: metadata}
+	if class.ttl > 0 {
+		info.ExpiresAt = now.Add(class.ttl).Unix()
+	}
+	return info, nil
+}
+
+// checkMintAllowed rejects mints of admin_mint classes that do not carry the
+// admin token, or any direct mint of them while a multisig admin wallet is
+// configured. Unknown classes are left for CreateNFT to report.
+func checkMintAllowed(r *http.Request, class string) error {
+	tokenClass, err := lookupClass(class)
+	if err != nil || !tokenClass.AdminMint {
+		return nil
+	}
+	if multisigRequired() {
+		return &APIError{http.StatusForbidden, codeForbidden, "class " + class + " can only be minted by a multisig proposal"}
+	}
+	if isAdmin(r) {
+		return nil
+	}
+	log.Warn("Rejected mint of admin-only class", "class", class, "remote", r.RemoteAddr)
+	return &APIError{http.StatusForbidden, codeForbidden, "class " + class + " can only be minted with the admin token"}
+}
+
+// HasClassTTL reports whether any configured class has a TTL, so the reaper
+// is needed to retire expired tokens.
+func HasClassTTL() bool {
+	for _, class := range tokenClasses {
+		if class.ttl > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// nftClass returns the class an NFT was minted in. Callers must hold
+// bc.mutex.
+func (bc *Blockchain) nftClass(nftId string) string {
+	if info, ok := bc.classes[nftId]; ok {
+		return info.Class
+	}
+	return ledgerID
+}
+
+// classRule returns the transfer rule that applies to nftId. NFTs whose
+// class is no longer configured keep the default host_only rule. Callers
+// must hold bc.mutex.
+func (bc *Blockchain) classRule(nftId string) string {
+	class, err := lookupClass(bc.nftClass(nftId))
+	if err != nil {
+		return TransferHostOnly
+	}
+	return class.Transfer
+}
+
+// expired reports whether nftId is past its class TTL at now. Callers must
+// hold bc.mutex.
+func (bc *Blockchain) expired(nftId string, now time.Time) bool {
+	info, ok := bc.classes[nftId]
+	return ok && info.ExpiresAt > 0 && now.Unix() >= info.ExpiresAt
+}
+
+// checkTransferRule reports whether the NFT's class lets its owner move it
+// to recipient, or burn it when burn is set. Callers must hold bc.mutex.
+func (bc *Blockchain) checkTransferRule(nftId, recipient string, burn bool) error {
+	class := bc.nftClass(nftId)
+	rule := bc.classRule(nftId)
+	var message string
+	switch {
+	case rule == TransferSoulbound:
+		message = "class " + class + " tokens are soulbound"
+	case burn:
+		return nil
+	case rule == TransferBurnOnly:
+		message = "class " + class + " tokens can only be burned"
+	case rule == TransferHostOnly && recipient != bc.HostWallet:
+		message = "class " + class + " tokens can only be transferred to the host wallet"
+	default:
+		return nil
+	}
+	return &APIError{http.StatusForbidden, codeClassRule, message}
+}
+
+// NFTClass returns the class record of nftId.
+func (bc *Blockchain) NFTClass(nftId string) (NFTClassInfo, error) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	if _, exists := bc.NFTs[nftId]; !exists {
+		return NFTClassInfo{}, ErrNFTNotFound
+	}
+	if info, ok := bc.classes[nftId]; ok {
+		return *info, nil
+	}
+	return NFTClassInfo{Class: ledgerID}, nil
+}
+
+// ClassesHandler lists the token classes this ledger accepts.
+func ClassesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TokenClasses())
+}
+
+// NFTClassHandler returns the class, metadata and expiry of the NFT in the
+// nft_id query parameter.
+func NFTClassHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	info, err := ledgerFor(r).NFTClass(r.URL.Query().Get("nft_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"testing"
+)
+
+// testClasses configures classes for the test.
+func testClasses(t *testing.T, classes ...*TokenClass) {
+	t.Helper()
+	prev := tokenClasses
+	t.Cleanup(func() { tokenClasses = prev })
+	if err := setTokenClasses(classes); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestClassTransferRules checks what each transfer rule lets an owner do.
+func TestClassTransferRules(t *testing.T) {
+	bc := testLedger(t)
+	key, owner := testWallet(t, bc)
+	_, other := testWallet(t, bc)
+	testClasses(t,
+		&TokenClass{Name: "pairing", Transfer: TransferFree},
+		&TokenClass{Name: "ticket", Transfer: TransferBurnOnly},
+		&TokenClass{Name: "override", Transfer: TransferSoulbound},
+	)
+
+	tests := []struct {
+		class                 string
+		toOther, toHost, burn bool
+	}{
+		{"", false, true, true},
+		{"pairing", true, true, true},
+		{"ticket", false, false, true},
+		{"override", false, false, false},
+	}
+	for _, tt := range tests {
+		name := tt.class
+		if name == "" {
+			name = "default"
+		}
+		t.Run(name, func(t *testing.T) {
+			ids := make([]string, 3)
+			for i := range ids {
+				ids[i] = name + "-" + string(rune('a'+i))
+				if _, err := bc.CreateNFT(owner, ids[i], nil, nil, tt.class, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := bc.TransferNFTTo(owner, other, ids[0], testSign(t, key, other+ids[0]))
+			if (err == nil) != tt.toOther {
+				t.Errorf("transfer to another address: error %v, want ok=%v", err, tt.toOther)
+			}
+			_, err = bc.TransferNFT(owner, ids[1], testSign(t, key, bc.HostWallet+ids[1]))
+			if (err == nil) != tt.toHost {
+				t.Errorf("transfer to the host wallet: error %v, want ok=%v", err, tt.toHost)
+			}
+			_, err = bc.BurnNFT(owner, ids[2], testSign(t, key, ids[2]+"burn"))
+			if (err == nil) != tt.burn {
+				t.Errorf("burn: error %v, want ok=%v", err, tt.burn)
+			}
+			if err != nil && errorCode(err) != codeClassRule {
+				t.Errorf("burn rejected with %v, want %s", err, codeClassRule)
+			}
+		})
+	}
+}
+
+func TestClassMetadata(t *testing.T) {
+	bc := testLedger(t)
+	_, owner := testWallet(t, bc)
+	testClasses(t, &TokenClass{Name: "pairing", Transfer: TransferFree, Metadata: []string{"device_id"}, TTL: "1h"})
+
+	if _, err := bc.CreateNFT(owner, "meta-bad", nil, nil, "pairing", map[string]string{"color": "red"}); err == nil {
+		t.Error("minted with a metadata key the class does not allow")
+	}
+	if _, err := bc.CreateNFT(owner, "meta-ok", nil, nil, "pairing", map[string]string{"device_id": "d1"}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := bc.NFTClass("meta-ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Class != "pairing" || info.Metadata["device_id"] != "d1" || info.ExpiresAt == 0 {
+		t.Errorf("class info %+v", info)
+	}
+}
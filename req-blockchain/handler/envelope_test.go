@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"testing"
+)
+
+// signEnvelope signs e for the default ledger with key.
+func signEnvelope(t *testing.T, key *ecdsa.PrivateKey, e TxEnvelope) TxEnvelope {
+	t.Helper()
+	e.Signature = testSign(t, key, string(e.SigningBytes("")))
+	return e
+}
+
+// errorCode returns the /v1 error code for err, or "" for nil.
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	_, body := errorBodyFor(err)
+	return body.Code
+}
+
+func TestSigningBytes(t *testing.T) {
+	prevID := ledgerID
+	t.Cleanup(func() { ledgerID = prevID })
+	ledgerID = "auth"
+
+	tests := []struct {
+		name   string
+		e      TxEnvelope
+		tenant string
+		want   string
+	}{
+		{
+			"empty payload",
+			TxEnvelope{Type: "burn", Sender: "s", NFTId: "nft-1", Nonce: 1},
+			"",
+			`{"ledger":"auth","tenant":"","type":"burn","sender":"s","nft_id":"nft-1","nonce":1,"payload":{}}`,
+		},
+		{
+			"payload keys sorted",
+			TxEnvelope{Type: "mint", Sender: "s", Nonce: 2, Payload: map[string]string{"owner": "o", "class": "c", "metadata.a": "1"}},
+			"",
+			`{"ledger":"auth","tenant":"","type":"mint","sender":"s","nft_id":"","nonce":2,"payload":{"class":"c","metadata.a":"1","owner":"o"}}`,
+		},
+		{
+			"tenant",
+			TxEnvelope{Type: "transfer", Sender: "s", NFTId: "nft-1", Nonce: 3, Payload: map[string]string{}},
+			"acme",
+			`{"ledger":"auth","tenant":"acme","type":"transfer","sender":"s","nft_id":"nft-1","nonce":3,"payload":{}}`,
+		},
+		{
+			"signature and proof not signed",
+			TxEnvelope{Type: "burn", Sender: "s", NFTId: "nft-1", Nonce: 1, Signature: "sig", AuthBurn: &BurnProof{TxHash: "h"}},
+			"",
+			`{"ledger":"auth","tenant":"","type":"burn","sender":"s","nft_id":"nft-1","nonce":1,"payload":{}}`,
+		},
+		{
+			"escaped values",
+			TxEnvelope{Type: "mint", Sender: "s", Nonce: 1, Payload: map[string]string{"metadata.note": `"<x>"`}},
+			"",
+			`{"ledger":"auth","tenant":"","type":"mint","sender":"s","nft_id":"","nonce":1,"payload":{"metadata.note":"\"\u003cx\u003e\""}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.e.SigningBytes(tt.tenant)); got != tt.want {
+				t.Errorf("SigningBytes\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEnvelopeNonces checks that each sender's nonces must increase, so an
+// envelope cannot be replayed, and that gaps are allowed.
+func TestEnvelopeNonces(t *testing.T) {
+	bc := testLedger(t)
+	key, sender := testWallet(t, bc)
+	_, other := testWallet(t, bc)
+
+	tests := []struct {
+		name  string
+		nonce uint64
+		owner string
+		code  string
+	}{
+		{"first", 1, "", ""},
+		{"replayed", 1, "", codeBadRequest},
+		{"reused for another mint", 1, other, codeBadNonce},
+		{"gap", 5, "", ""},
+		{"below the last", 3, other, codeBadNonce},
+		{"zero", 0, other, codeBadRequest},
+		{"after the gap", 6, other, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := TxEnvelope{Type: "mint", Sender: sender, Nonce: tt.nonce}
+			if tt.owner != "" {
+				e.Payload = map[string]string{"owner": tt.owner}
+			}
+			_, err := bc.SubmitEnvelope(signEnvelope(t, key, e))
+			if code := errorCode(err); code != tt.code {
+				t.Errorf("error %v, want code %q", err, tt.code)
+			}
+		})
+	}
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	if last := bc.nonces[sender]; last != 6 {
+		t.Errorf("last nonce %d, want 6", last)
+	}
+}
+
+// TestEnvelopeAuthBurn checks that a mint's auth_burn proof must be the one
+// its signed payload names.
+func TestEnvelopeAuthBurn(t *testing.T) {
+	proof, host := testAuthBurn(t, "")
+	other, _ := testAuthBurn(t, "")
+
+	bc := testLedger(t)
+	key, sender := testWallet(t, bc)
+	prevID, prevHosts := ledgerID, authHosts
+	t.Cleanup(func() { ledgerID, authHosts = prevID, prevHosts })
+	ledgerID = "req"
+	if err := RequireAuthBurns([]string{host}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		typ     string
+		payload string
+		proof   *BurnProof
+		ok      bool
+	}{
+		{"payload names a burn, no proof", "mint", proof.TxHash, nil, false},
+		{"proof not named in payload", "mint", "", proof, false},
+		{"proof for another burn", "mint", proof.TxHash, other, false},
+		{"proof on a burn envelope", "burn", proof.TxHash, proof, false},
+		{"no proof on the req ledger", "mint", "", nil, false},
+		{"matching", "mint", proof.TxHash, proof, true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := TxEnvelope{Type: tt.typ, Sender: sender, Nonce: uint64(i + 1), AuthBurn: tt.proof}
+			if tt.typ == "burn" {
+				e.NFTId = "nft-1"
+			}
+			if tt.payload != "" {
+				e.Payload = map[string]string{"auth_burn": tt.payload}
+			}
+			receipt, err := bc.SubmitEnvelope(signEnvelope(t, key, e))
+			if (err == nil) != tt.ok {
+				t.Fatalf("submit error %v, want ok=%v", err, tt.ok)
+			}
+			if !tt.ok {
+				return
+			}
+			origin, err := bc.Origin(receipt.NFTId)
+			if err != nil || origin.TxHash != proof.TxHash {
+				t.Errorf("origin %+v, %v; want burn %s", origin, err, proof.TxHash)
+			}
+		})
+	}
+}
+
+// TestSimulateMatchesSubmit checks that simulating an envelope predicts what
+// submitting it does.
+func TestSimulateMatchesSubmit(t *testing.T) {
+	bc := testLedger(t)
+	key, owner := testWallet(t, bc)
+	otherKey, other := testWallet(t, bc)
+	ids := testMint(t, bc, owner, "sim", 2)
+
+	tests := []struct {
+		name string
+		key  *ecdsa.PrivateKey
+		e    TxEnvelope
+		code string
+	}{
+		{"mint", key, TxEnvelope{Type: "mint", Sender: owner, Nonce: 1}, ""},
+		{"transfer off the host wallet", key, TxEnvelope{Type: "transfer", Sender: owner, NFTId: ids[0], Nonce: 2,
+			Payload: map[string]string{"recipient": other}}, codeClassRule},
+		{"not the owner", otherKey, TxEnvelope{Type: "burn", Sender: other, NFTId: ids[0], Nonce: 1}, ErrNotOwner.Code},
+		{"signed by another key", otherKey, TxEnvelope{Type: "burn", Sender: owner, NFTId: ids[0], Nonce: 2}, ErrBadSignature.Code},
+		{"stale nonce", key, TxEnvelope{Type: "burn", Sender: owner, NFTId: ids[0], Nonce: 1}, codeBadNonce},
+		{"burn", key, TxEnvelope{Type: "burn", Sender: owner, NFTId: ids[0], Nonce: 2}, ""},
+		{"burned", key, TxEnvelope{Type: "burn", Sender: owner, NFTId: ids[0], Nonce: 3}, ErrBurned.Code},
+		{"transfer to the host wallet", key, TxEnvelope{Type: "transfer", Sender: owner, NFTId: ids[1], Nonce: 3}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := signEnvelope(t, tt.key, tt.e)
+			sim, err := bc.SimulateEnvelope(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			receipt, err := bc.SubmitEnvelope(e)
+			if code := errorCode(err); code != tt.code {
+				t.Fatalf("submit error %v, want code %q", err, tt.code)
+			}
+
+			if sim.Applies != (err == nil) {
+				t.Errorf("simulation applies=%v, submit error %v", sim.Applies, err)
+			}
+			if sim.Receipt != receipt {
+				t.Errorf("simulated receipt %+v, submitted %+v", sim.Receipt, receipt)
+			}
+			if err != nil {
+				if sim.Error == nil || sim.Error.Code != tt.code {
+					t.Errorf("simulated error %+v, want code %q", sim.Error, tt.code)
+				}
+				return
+			}
+			if got := ownerOf(t, receipt.NFTId); sim.Owner != got {
+				t.Errorf("simulated owner %s, submitted %s", sim.Owner, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestFreezeBlocksMutations checks that a frozen NFT or wallet cannot move
+// until it is unfrozen, and that the compliance history records both.
+func TestFreezeBlocksMutations(t *testing.T) {
+	bc := testLedger(t)
+	key, owner := testWallet(t, bc)
+	ids := testMint(t, bc, owner, "frozen", 2)
+	transfer := func(nftId string) error {
+		_, err := bc.TransferNFT(owner, nftId, testSign(t, key, bc.HostWallet+nftId))
+		return err
+	}
+	burn := func(nftId string) error {
+		_, err := bc.BurnNFT(owner, nftId, testSign(t, key, nftId+"burn"))
+		return err
+	}
+	setFrozen := func(kind, target string, freeze bool) error {
+		_, err := bc.SetFrozen(FreezeRecord{Kind: kind, Target: target, Reason: "investigation", Actor: "ops"}, freeze, time.Now())
+		return err
+	}
+
+	if err := setFrozen(FreezeNFT, ids[0], true); err != nil {
+		t.Fatal(err)
+	}
+	if err := setFrozen(FreezeNFT, ids[0], true); err == nil {
+		t.Error("froze an NFT twice")
+	}
+	if err := transfer(ids[0]); !errors.Is(err, ErrNFTFrozen) {
+		t.Errorf("transfer of a frozen NFT: %v", err)
+	}
+	if err := burn(ids[0]); !errors.Is(err, ErrNFTFrozen) {
+		t.Errorf("burn of a frozen NFT: %v", err)
+	}
+	if validate(t, ids[0], owner) {
+		t.Error("frozen NFT validated")
+	}
+	if !validate(t, ids[1], owner) {
+		t.Error("NFT validation failed while another NFT is frozen")
+	}
+
+	if err := setFrozen(FreezeWallet, owner, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := burn(ids[1]); !errors.Is(err, ErrWalletFrozen) {
+		t.Errorf("burn from a frozen wallet: %v", err)
+	}
+	if err := setFrozen(FreezeWallet, bc.HostWallet, true); err == nil {
+		t.Error("froze the host wallet")
+	}
+
+	if err := setFrozen(FreezeWallet, owner, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := setFrozen(FreezeNFT, ids[0], false); err != nil {
+		t.Fatal(err)
+	}
+	if err := setFrozen(FreezeNFT, ids[0], false); err == nil {
+		t.Error("unfroze an NFT that is not frozen")
+	}
+	if err := transfer(ids[0]); err != nil {
+		t.Errorf("transfer after unfreeze: %v", err)
+	}
+	if err := burn(ids[1]); err != nil {
+		t.Errorf("burn after unfreeze: %v", err)
+	}
+
+	active, history := bc.Compliance(ids[0])
+	if len(active) != 0 {
+		t.Errorf("active freezes %+v after unfreeze", active)
+	}
+	if len(history) != 2 || history[0].Action != "freeze" || history[1].Action != "unfreeze" {
+		t.Fatalf("compliance history %+v, want freeze then unfreeze", history)
+	}
+	if history[0].Record.Reason != "investigation" || history[0].Receipt.Status != "pending" {
+		t.Errorf("freeze entry %+v", history[0])
+	}
+	if _, err := bc.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, history := bc.Compliance(ids[0]); history[0].Receipt.Status != "sealed" {
+		t.Errorf("freeze receipt %+v after mining", history[0].Receipt)
+	}
+}
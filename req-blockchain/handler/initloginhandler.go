@@ -12,17 +12,14 @@ import (
 )
 
 // VerifySignature validates a message signature against a public address
-func VerifySignature(address string, message string, signature string) (bool, error) {
+func (bc *Blockchain) VerifySignature(address string, message string, signature string) (bool, error) {
 	// 1. Validate address format
-	if valid, err := ValidatePublicAddress(address); !valid {
+	if valid, err := bc.ValidatePublicAddress(address); !valid {
 		return false, fmt.Errorf("invalid address: %v", err)
 	}
 
 	// 2. Retrieve public key from blockchain
-	blockchain.mutex.Lock()
-	defer blockchain.mutex.Unlock()
-	
-	wallet, exists := blockchain.Wallets[address]
+	wallet, exists := bc.wallet(address)
 	if !exists {
 		return false, errors.New("wallet not found")
 	}
@@ -38,6 +35,9 @@ func VerifySignature(address string, message string, signature string) (bool, er
 
 	// 5. Verify the signature
 	valid := ecdsa.VerifyASN1(wallet, hash[:], sigBytes)
+	if !valid {
+		validationFailures.Inc("bad_signature")
+	}
 	return valid, nil
 }
 
@@ -56,7 +56,7 @@ func SignatureValidationHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	valid, err := VerifySignature(req.Address, req.Message, req.Signature)
+	valid, err := ledgerFor(r).VerifySignature(req.Address, req.Message, req.Signature)
 	response := map[string]interface{}{
 		"valid":  valid,
 		"error":  nil,
@@ -78,7 +78,7 @@ func SignatureValidationHandler(w http.ResponseWriter, r *http.Request) {
 // }
 
 // ValidatePublicAddress checks if a public address is properly formatted and exists in the system
-func ValidatePublicAddress(address string) (bool, error) {
+func (bc *Blockchain) ValidatePublicAddress(address string) (bool, error) {
 	fmt.Println("Starting ValidatePublicAddress with address:", address)
 
 	// Normalize the address (e.g., trim spaces and convert to lowercase)
@@ -97,13 +97,10 @@ func ValidatePublicAddress(address string) (bool, error) {
 		return false, errors.New("invalid hexadecimal format")
 	}
 
-	blockchain.mutex.Lock()
-	defer blockchain.mutex.Unlock()
-	fmt.Println("Acquired blockchain mutex lock")
-
 	// Check if address exists in wallets
-	_, exists := blockchain.Wallets[normalizedAddress]
+	_, exists := bc.wallet(normalizedAddress)
 	if !exists {
+		validationFailures.Inc("unknown_wallet")
 		fmt.Println("Address not found in registry:", normalizedAddress)
 		return false, errors.New("address not found in registry")
 	}
@@ -135,7 +132,7 @@ func ValidateAddressHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	fmt.Println("Validating address:", req.Address)
-	valid, err := ValidatePublicAddress(req.Address)
+	valid, err := ledgerFor(r).ValidatePublicAddress(req.Address)
 	fmt.Println("Validation result - valid:", valid, "error:", err)
 
 	response := map[string]interface{}{
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"blockchain-api/metrics"
+)
+
+// Ledger metrics exposed on /metrics.
+var (
+	nftsMinted = metrics.NewCounterVec("ledger_nfts_minted_total",
+		"NFTs minted.")
+	nftsTransferred = metrics.NewCounterVec("ledger_nfts_transferred_total",
+		"NFTs transferred.")
+	nftsBurned = metrics.NewCounterVec("ledger_nfts_burned_total",
+		"NFTs burned.")
+	validationFailures = metrics.NewCounterVec("ledger_validation_failures_total",
+		"Failed ownership, address and signature validations, by reason.", "reason")
+	powDuration = metrics.NewHistogramVec("ledger_pow_duration_seconds",
+		"Time spent searching for a proof of work.", []float64{.001, .01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30})
+	blocksMined = metrics.NewCounterVec("ledger_blocks_mined_total",
+		"Blocks appended to the chain.")
+	blocksPruned = metrics.NewCounterVec("ledger_blocks_pruned_total",
+		"Block bodies moved to the archive.")
+	eventsEmitted = metrics.NewCounterVec("ledger_events_emitted_total",
+		"Ledger events emitted, by type.", "type")
+	reaperRuns = metrics.NewCounterVec("ledger_reaper_runs_total",
+		"Reaper passes over the ledger.")
+	reaperReaped = metrics.NewCounterVec("ledger_reaper_nfts_total",
+		"Expired NFTs found by the reaper, by reason and whether they were burned or only reported.", "reason", "mode")
+	policyViolations = metrics.NewCounterVec("ledger_policy_violations_total",
+		"Mutations rejected by the policy engine, by rule ID.", "rule")
+	anchorsPublished = metrics.NewCounterVec("ledger_anchors_published_total",
+		"Anchors of this ledger's tip submitted to the peer ledger, by result.", "result")
+	anchorConflicts = metrics.NewCounterVec("ledger_anchor_conflicts_total",
+		"Peer ledger anchors refused because they contradict earlier anchors.")
+)
+
+func init() {
+	metrics.NewGaugeFunc("ledger_mempool_transactions",
+		"Transactions waiting to be mined, across all tenants.", func() float64 {
+			total := 0
+			for _, bc := range allLedgers() {
+				bc.mutex.RLock()
+				total += len(bc.CurrentTransactions)
+				bc.mutex.RUnlock()
+			}
+			return float64(total)
+		})
+	metrics.NewGaugeFunc("ledger_chain_height",
+		"Number of blocks in the default ledger's chain.", func() float64 {
+			return float64(len(blockchain.view.Load().blocks))
+		})
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"time"
+)
+
+// StartMiner seals pending transactions into a block on every ledger each
+// interval, 10 seconds if it is not positive. Ledgers with an empty mempool
+// are skipped so chains only grow with activity.
+func StartMiner(interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			for _, bc := range allLedgers() {
+				bc.mutex.RLock()
+				pending := len(bc.CurrentTransactions)
+				bc.mutex.RUnlock()
+				if pending == 0 {
+					continue
+				}
+				if _, err := bc.MineBlock(); err != nil {
+					log.Error("Failed to mine block", "tenant", bc.tenant, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// EnablePruning turns on pruning for the default ledger and every tenant.
+// Each ledger archives under its own genesis hash in dir.
+func EnablePruning(dir string, keepBlocks int) error {
+	for _, bc := range allLedgers() {
+		if err := bc.EnablePruning(dir, keepBlocks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
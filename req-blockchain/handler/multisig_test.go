@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testKey is a registered wallet and its key.
+type testKey struct {
+	key     *ecdsa.PrivateKey
+	address string
+}
+
+// testMultisig configures a threshold-of-n admin wallet for the test and
+// returns its signers' keys.
+func testMultisig(t *testing.T, bc *Blockchain, threshold, n int) []testKey {
+	t.Helper()
+	prev := multisig
+	t.Cleanup(func() { multisig = prev })
+
+	keys := make([]testKey, n)
+	signers := make([]string, n)
+	for i := range keys {
+		keys[i].key, keys[i].address = testWallet(t, bc)
+		signers[i] = keys[i].address
+	}
+	data, _ := json.Marshal(map[string]interface{}{"threshold": threshold, "signers": signers})
+	path := filepath.Join(t.TempDir(), "multisig.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadMultisig(path); err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// TestProposalBoundToTenant checks that signatures collected for a proposal
+// on one tenant cannot run the same proposal on another.
+func TestProposalBoundToTenant(t *testing.T) {
+	tenantA := testLedger(t)
+	tenantA.tenant = "a"
+	tenantB := NewBlockchain()
+	tenantB.tenant = "b"
+	tenantB.CreateGenesisBlock()
+
+	signers := testMultisig(t, tenantA, 2, 2)
+	_, user := testWallet(t, tenantA)
+	now := time.Now()
+	p := Proposal{Action: ProposalRegisterUser, Target: user, Username: "alice", UserType: "admin", ExpiresAt: now.Add(time.Hour).Unix()}
+
+	first := testSign(t, signers[0].key, ProposalMessage(p, "a"))
+	created, err := tenantA.Propose(p, signers[0].address, first, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tenantB.Propose(p, signers[0].address, first, now); err == nil {
+		t.Error("tenant b accepted a proposal signed for tenant a")
+	}
+
+	second := testSign(t, signers[1].key, ProposalMessage(p, "a"))
+	if _, err := tenantB.SignProposal(created.ID, signers[1].address, second, now); err == nil {
+		t.Error("tenant b accepted a signature for tenant a's proposal")
+	}
+	expire := testSign(t, signers[1].key, ExpireProposalMessage(created.ID, "b"))
+	if err := tenantA.ExpireProposal(created.ID, signers[1].address, expire); err == nil {
+		t.Error("tenant a accepted an expiry signed for tenant b")
+	}
+
+	onB, err := tenantB.Propose(p, signers[0].address, testSign(t, signers[0].key, ProposalMessage(p, "b")), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if onB.ID == created.ID {
+		t.Error("the same proposal has the same ID on both tenants")
+	}
+	if _, err := tenantA.SignProposal(created.ID, signers[1].address, second, now); err != nil {
+		t.Errorf("second signature on tenant a: %v", err)
+	}
+}
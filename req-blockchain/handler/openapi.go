@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"bytes"
+	_ "embed"
+	"net/http"
+	"text/template"
+)
+
+//go:embed openapi.json
+var openAPISource string
+
+var openAPITemplate = template.Must(template.New("openapi").Parse(openAPISource))
+
+// OpenAPIHandler serves the OpenAPI document for the /v1 routes under
+// nftPrefix.
+func OpenAPIHandler(nftPrefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		err := openAPITemplate.Execute(&buf, struct{ Ledger, NFTPrefix string }{ledgerID, nftPrefix})
+		if err != nil {
+			log.Error("Failed to render OpenAPI document", "error", err)
+			writeError(w, &APIError{http.StatusInternalServerError, codeInternal, "failed to render OpenAPI document"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(buf.Bytes())
+	}
+}
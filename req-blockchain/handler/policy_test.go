@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPolicy loads rules, a JSON array, for the test.
+func testPolicy(t *testing.T, rules string) error {
+	t.Helper()
+	prev := policyRules
+	t.Cleanup(func() { policyRules = prev })
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(rules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadPolicy(path)
+}
+
+func TestLoadPolicyRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{"no id", `[{"kind": "max_live_per_owner", "max": 1}]`},
+		{"duplicate id", `[{"id": "a", "kind": "max_live_per_owner", "max": 1}, {"id": "a", "kind": "max_live_per_owner", "max": 2}]`},
+		{"unknown kind", `[{"id": "a", "kind": "max_per_day", "max": 1}]`},
+		{"max below 1", `[{"id": "a", "kind": "max_live_per_owner", "max": 0}]`},
+		{"invalid window", `[{"id": "a", "kind": "mint_rate", "max": 1, "window": "soon"}]`},
+		{"empty deny list", `[{"id": "a", "kind": "deny_list"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := testPolicy(t, tt.rules); err == nil {
+				t.Error("invalid policy loaded")
+			}
+		})
+	}
+}
+
+// TestPolicyRules checks each rule kind against the mutations it limits.
+func TestPolicyRules(t *testing.T) {
+	bc := testLedger(t)
+	key, owner := testWallet(t, bc)
+	deniedKey, denied := testWallet(t, bc)
+	deniedNFT := testMint(t, bc, denied, "denied", 1)[0]
+
+	err := testPolicy(t, `[
+		{"id": "blocked", "kind": "deny_list", "addresses": ["`+denied+`"]},
+		{"id": "one-session", "kind": "max_live_per_owner", "max": 1},
+		{"id": "mint-burst", "kind": "mint_rate", "max": 2, "window": "1m"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mint := func(owner, nftId string) func() error {
+		return func() error {
+			_, err := bc.CreateNFT(owner, nftId, nil, nil, "", nil)
+			return err
+		}
+	}
+	burnThenMint := func(burned, minted string) func() error {
+		return func() error {
+			if _, err := bc.BurnNFT(owner, burned, testSign(t, key, burned+"burn")); err != nil {
+				return err
+			}
+			return mint(owner, minted)()
+		}
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+		rule string
+	}{
+		{"first mint", mint(owner, "live-1"), ""},
+		{"second live NFT", mint(owner, "live-2"), "one-session"},
+		{"mint after a burn", burnThenMint("live-1", "live-3"), ""},
+		{"third mint within the window", burnThenMint("live-3", "live-4"), "mint-burst"},
+		{"mint to a denied address", mint(denied, "denied-2"), "blocked"},
+		{"burn by a denied address", func() error {
+			_, err := bc.BurnNFT(denied, deniedNFT, testSign(t, deniedKey, deniedNFT+"burn"))
+			return err
+		}, "blocked"},
+		{"register a denied address", func() error { return bc.RegisterUser(denied, "mallory", "user") }, "blocked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			violation, ok := asPolicyViolation(err)
+			switch {
+			case tt.rule == "" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case tt.rule != "" && (!ok || violation.RuleID != tt.rule):
+				t.Errorf("error %v, want a violation of %s", err, tt.rule)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// nftActivity tracks when an NFT was minted and last used, which is what the
+// reaper measures session TTL and idle time against. LastSeen and Reported
+// are updated atomically so validations can touch an NFT under the read lock.
+type nftActivity struct {
+	MintedAt time.Time
+	lastSeen atomic.Int64 // Unix nanoseconds
+	reported atomic.Bool  // A dry-run pass has already reported this NFT
+}
+
+func newActivity(now time.Time) *nftActivity {
+	a := &nftActivity{MintedAt: now}
+	a.lastSeen.Store(now.UnixNano())
+	return a
+}
+
+// LastSeen returns when the NFT was last used.
+func (a *nftActivity) LastSeen() time.Time {
+	return time.Unix(0, a.lastSeen.Load())
+}
+
+// touchNFT marks an NFT as used now. Callers must hold bc.mutex for reading
+// or writing.
+func (bc *Blockchain) touchNFT(nftId string) {
+	if a, ok := bc.activity[nftId]; ok {
+		a.lastSeen.Store(time.Now().UnixNano())
+		a.reported.Store(false)
+	}
+}
+
+// ReaperConfig controls the expired NFT reaper. A zero SessionTTL or
+// IdleTimeout disables that limit.
+type ReaperConfig struct {
+	Interval    time.Duration // Time between passes
+	SessionTTL  time.Duration // Maximum age since mint
+	IdleTimeout time.Duration // Maximum time since the last successful validation
+	Grace       time.Duration // Extra time allowed past either limit
+	DryRun      bool          // Report expired NFTs without burning them
+}
+
+// reapCandidate is an NFT the reaper has found past its limits.
+type reapCandidate struct {
+	nftId  string
+	owner  string
+	reason string // "ttl", "idle" or "class_ttl"
+}
+
+// expiredNFTs returns the NFTs past their TTL, idle limit or class TTL plus
+// grace, skipping those already held by the host wallet. Callers must hold bc.mutex.
+func (bc *Blockchain) expiredNFTs(config ReaperConfig, now time.Time) []reapCandidate {
+	var expired []reapCandidate
+	for id, a := range bc.activity {
+		owner, exists := bc.NFTs[id]
+		if !exists || owner == bc.HostWallet {
+			continue
+		}
+		// Frozen NFTs are kept as evidence until the freeze is lifted.
+		if bc.frozen(id, owner) != nil {
+			continue
+		}
+		switch {
+		case bc.expired(id, now.Add(-config.Grace)):
+			expired = append(expired, reapCandidate{nftId: id, owner: owner, reason: "class_ttl"})
+		case config.SessionTTL > 0 && now.After(a.MintedAt.Add(config.SessionTTL+config.Grace)):
+			expired = append(expired, reapCandidate{nftId: id, owner: owner, reason: "ttl"})
+		case config.IdleTimeout > 0 && now.After(a.LastSeen().Add(config.IdleTimeout+config.Grace)):
+			expired = append(expired, reapCandidate{nftId: id, owner: owner, reason: "idle"})
+		}
+	}
+	return expired
+}
+
+// Reap burns every expired NFT with a host-signed transaction, or only
+// reports them in dry-run mode. It returns the number of NFTs found.
+func (bc *Blockchain) Reap(config ReaperConfig, now time.Time) int {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	reaperRuns.Inc()
+	expired := bc.expiredNFTs(config, now)
+	for _, c := range expired {
+		fields := map[string]string{"owner": c.owner, "reason": c.reason}
+
+		if config.DryRun {
+			if bc.activity[c.nftId].reported.Swap(true) {
+				continue
+			}
+			reaperReaped.Inc(c.reason, "dry_run")
+			bc.emitEvent("nft.expired", c.nftId, fields)
+			continue
+		}
+
+		if _, err := bc.hostBurn(c.nftId, c.owner); err != nil {
+			log.Error("Failed to sign reaper burn", "nft_id", c.nftId, "error", err)
+			continue
+		}
+		reaperReaped.Inc(c.reason, "burned")
+		bc.emitEvent("nft.reaped", c.nftId, fields)
+	}
+	return len(expired)
+}
+
+// hostBurn burns nftId, held by owner, with a host-signed transaction.
+// Callers must hold bc.mutex for writing.
+func (bc *Blockchain) hostBurn(nftId, owner string) (Receipt, error) {
+	signature, err := bc.hostSign([]byte(nftId + "burn"))
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	bc.NFTs[nftId] = bc.HostWallet
+	delete(bc.activity, nftId)
+	bc.markBurned(nftId)
+	receipt := bc.addTransaction(Transaction{
+		Sender:    owner,
+		Recipient: bc.HostWallet,
+		NFTId:     nftId,
+		Signature: signature,
+		Type:      "burn",
+		Signer:    bc.HostWallet,
+	})
+	nftsBurned.Inc()
+	return receipt, nil
+}
+
+// StartReaper runs Reap over the served ledger every config.Interval.
+func StartReaper(config ReaperConfig) {
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	log.Info("Starting reaper", "interval", config.Interval, "session_ttl", config.SessionTTL,
+		"idle_timeout", config.IdleTimeout, "grace", config.Grace, "dry_run", config.DryRun)
+
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			for _, bc := range allLedgers() {
+				if n := bc.Reap(config, now); n > 0 {
+					log.Info("Reaper pass complete", "tenant", bc.tenant, "expired", n, "dry_run", config.DryRun)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// rejectedBufferSize is the number of recent rejected transactions kept for
+// /tx lookups.
+const rejectedBufferSize = 1000
+
+// Receipt reports what became of a transaction.
+type Receipt struct {
+	TxHash        string `json:"tx_hash"`
+	Type          string `json:"type"` // "mint", "transfer" or "burn"
+	NFTId         string `json:"nft_id"`
+	Status        string `json:"status"`                  // "pending", "sealed" or "rejected"
+	BlockIndex    int    `json:"block_index,omitempty"`   // Set once sealed
+	Confirmations int    `json:"confirmations,omitempty"` // Blocks from BlockIndex to the tip, inclusive
+	Reason        string `json:"reason,omitempty"`        // Why a rejected transaction was refused
+}
+
+// sealedTx locates a sealed transaction.
+type sealedTx struct {
+	blockIndex int
+	nftId      string
+	txType     string
+}
+
+// rejectedLog is an in-memory ring of recently rejected transactions.
+type rejectedLog struct {
+	mu       sync.Mutex
+	receipts map[string]Receipt
+	order    []string
+}
+
+// TxHash returns the hash of a transaction: the SHA-256 of its JSON
+// encoding, as it is stored in blocks. It depends only on the transaction's
+// contents, so a client can compute it for a signed transfer or burn before
+// submitting it. A replayed transaction has the same hash as the original;
+// lookups report the latest.
+func TxHash(tx Transaction) string {
+	txBytes, _ := json.Marshal(tx)
+	hash := sha256.Sum256(txBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+// txType returns the receipt type of tx. Mints carry no type.
+func txType(tx Transaction) string {
+	if tx.Type == "" {
+		return "mint"
+	}
+	return tx.Type
+}
+
+// addTransaction queues tx for the next block and returns its receipt.
+// Callers must hold bc.mutex for writing.
+func (bc *Blockchain) addTransaction(tx Transaction) Receipt {
+	bc.CurrentTransactions = append(bc.CurrentTransactions, tx)
+	if tx.Nonce > 0 {
+		bc.nonces[tx.Sender] = tx.Nonce
+	}
+	return Receipt{TxHash: TxHash(tx), Type: txType(tx), NFTId: tx.NFTId, Status: "pending"}
+}
+
+// rejectTransaction records that tx was refused with err and returns its
+// receipt.
+func (bc *Blockchain) rejectTransaction(tx Transaction, err error) Receipt {
+	receipt := Receipt{TxHash: TxHash(tx), Type: txType(tx), NFTId: tx.NFTId, Status: "rejected", Reason: err.Error()}
+
+	rejected := bc.rejected
+	rejected.mu.Lock()
+	defer rejected.mu.Unlock()
+	if _, exists := rejected.receipts[receipt.TxHash]; !exists {
+		rejected.order = append(rejected.order, receipt.TxHash)
+	}
+	rejected.receipts[receipt.TxHash] = receipt
+	if len(rejected.order) > rejectedBufferSize {
+		delete(rejected.receipts, rejected.order[0])
+		rejected.order = rejected.order[1:]
+	}
+	return receipt
+}
+
+// Receipt looks up a transaction by hash. Pending and sealed transactions
+// are found for as long as the node has them; rejections only while they
+// are among the most recent.
+func (bc *Blockchain) Receipt(txHash string) (Receipt, error) {
+	bc.mutex.RLock()
+	for i := len(bc.CurrentTransactions) - 1; i >= 0; i-- {
+		if tx := bc.CurrentTransactions[i]; TxHash(tx) == txHash {
+			bc.mutex.RUnlock()
+			return Receipt{TxHash: txHash, Type: txType(tx), NFTId: tx.NFTId, Status: "pending"}, nil
+		}
+	}
+	if sealed, ok := bc.txIndex[txHash]; ok {
+		confirmations := len(bc.Chain) - sealed.blockIndex + 1
+		bc.mutex.RUnlock()
+		return Receipt{TxHash: txHash, Type: sealed.txType, NFTId: sealed.nftId, Status: "sealed",
+			BlockIndex: sealed.blockIndex, Confirmations: confirmations}, nil
+	}
+	bc.mutex.RUnlock()
+
+	rejected := bc.rejected
+	rejected.mu.Lock()
+	defer rejected.mu.Unlock()
+	if receipt, ok := rejected.receipts[txHash]; ok {
+		return receipt, nil
+	}
+	return Receipt{}, ErrTxNotFound
+}
+
+// TxHandler reports the status of the transaction in GET /tx/{hash}.
+func TxHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	txHash := strings.TrimPrefix(r.URL.Path, "/tx/")
+	if txHash == "" || strings.Contains(txHash, "/") {
+		http.Error(w, "Missing transaction hash", http.StatusBadRequest)
+		return
+	}
+
+	receipt, err := ledgerFor(r).Receipt(strings.ToLower(txHash))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipt)
+}
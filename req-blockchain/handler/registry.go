@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Allowed user and token types, as in AuthToken.sol.
+var (
+	allowedUserTypes  = []string{"admin", "user", "guest"}
+	allowedTokenTypes = []string{"login", "session"}
+)
+
+// UserInfo is a registered user, as in AuthToken.sol's registeredUsers.
+type UserInfo struct {
+	Username string `json:"username"`
+	UserType string `json:"user_type"`
+}
+
+// TokenDetails describes a minted token, as in AuthToken.sol's tokenMetadata.
+// Active is cleared when the token is burned.
+type TokenDetails struct {
+	Username  string `json:"username"`
+	UserType  string `json:"user_type"`
+	TokenType string `json:"token_type"`
+	Active    bool   `json:"active"`
+}
+
+func isAllowedValue(value string, allowed []string) error {
+	if value == "" {
+		return errors.New("value cannot be empty")
+	}
+	for _, v := range allowed {
+		if v == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of %v", value, allowed)
+}
+
+// RegisterUser records the user behind a wallet address, like registerUser
+// in AuthToken.sol. Registering an address again replaces its details.
+func (bc *Blockchain) RegisterUser(address, username, userType string) error {
+	if _, err := publicKeyFromAddress(address); err != nil {
+		return fmt.Errorf("invalid user address: %w", err)
+	}
+	if username == "" {
+		return errors.New("username cannot be empty")
+	}
+	if err := isAllowedValue(userType, allowedUserTypes); err != nil {
+		return fmt.Errorf("invalid user type: %w", err)
+	}
+
+	bc.mutex.Lock()
+	if err := bc.checkPolicy(mutation{action: "register", target: address}, time.Now()); err != nil {
+		bc.mutex.Unlock()
+		return err
+	}
+	bc.users[address] = UserInfo{Username: username, UserType: userType}
+	bc.mutex.Unlock()
+
+	bc.emitEvent("user.registered", "", map[string]string{
+		"user": address, "username": username, "user_type": userType,
+	})
+	return nil
+}
+
+// User returns the registration for address.
+func (bc *Blockchain) User(address string) (UserInfo, bool) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	user, ok := bc.users[address]
+	return user, ok
+}
+
+// TokenDetails returns the details bound to nftId at mint.
+func (bc *Blockchain) TokenDetails(nftId string) (TokenDetails, error) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	if _, exists := bc.NFTs[nftId]; !exists {
+		return TokenDetails{}, ErrNFTNotFound
+	}
+	details, ok := bc.tokens[nftId]
+	if !ok {
+		return TokenDetails{}, errors.New("NFT was minted without token details")
+	}
+	return *details, nil
+}
+
+// checkTokenDetails validates details for a mint to owner the way mintToken
+// in AuthToken.sol does. Callers must hold bc.mutex.
+func (bc *Blockchain) checkTokenDetails(owner string, details *TokenDetails) error {
+	if details.Username == "" {
+		return errors.New("username cannot be empty")
+	}
+	if err := isAllowedValue(details.UserType, allowedUserTypes); err != nil {
+		return fmt.Errorf("invalid user type: %w", err)
+	}
+	if err := isAllowedValue(details.TokenType, allowedTokenTypes); err != nil {
+		return fmt.Errorf("invalid token type: %w", err)
+	}
+
+	user, registered := bc.users[owner]
+	if !registered {
+		return errors.New("user is not registered")
+	}
+	if details.Username != user.Username {
+		return errors.New("provided username does not match registered username")
+	}
+	if details.UserType != user.UserType {
+		return errors.New("provided user type does not match registered user type")
+	}
+	return nil
+}
+
+// mintDetails returns the details to bind to a mint to owner. Every AuthNFT
+// is bound to a registered user: when details is nil the auth ledger mints a
+// login token with the details owner was registered with, as the Ethereum
+// backend does. Other ledgers bind details only when given. Callers must hold
+// bc.mutex.
+func (bc *Blockchain) mintDetails(owner string, details *TokenDetails) (*TokenDetails, error) {
+	if details != nil || ledgerID != "auth" {
+		return details, nil
+	}
+	user, registered := bc.users[owner]
+	if !registered {
+		return nil, errors.New("user is not registered")
+	}
+	return &TokenDetails{Username: user.Username, UserType: user.UserType, TokenType: "login"}, nil
+}
+
+// deactivateToken marks a burned token inactive. Callers must hold bc.mutex.
+func (bc *Blockchain) deactivateToken(nftId string) {
+	if details, ok := bc.tokens[nftId]; ok {
+		details.Active = false
+	}
+}
+
+// RegisterUserHandler registers a user. It is an admin endpoint, matching the
+// contract's restriction of registerUser to the server account. While a
+// multisig admin wallet is configured, users are registered by proposal.
+func RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if multisigRequired() {
+		http.Error(w, "User registration requires a multisig proposal", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		Address  string `json:"address"`
+		Username string `json:"username"`
+		UserType string `json:"user_type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to decode request body", err)
+		return
+	}
+
+	if err := ledgerFor(r).RegisterUser(req.Address, req.Username, req.UserType); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Failed to register user", "username", req.Username, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]bool{"registered": true})
+}
+
+// GetUserHandler returns the registration for the address query parameter.
+func GetUserHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	address := r.URL.Query().Get("address")
+	user, ok := ledgerFor(r).User(address)
+	if !ok {
+		http.Error(w, "User is not registered", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// TokenDetailsHandler returns the details of the NFT in the nft_id query
+// parameter, like getTokenDetails in AuthToken.sol.
+func TokenDetailsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	details, err := ledgerFor(r).TokenDetails(r.URL.Query().Get("nft_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
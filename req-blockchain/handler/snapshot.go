@@ -0,0 +1,508 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SnapshotVersion is the archive format written by ExportSnapshot.
+const SnapshotVersion = 1
+
+// Snapshot is a portable copy of a ledger's state.
+type Snapshot struct {
+	Version    int               `json:"version"`
+	Ledger     string            `json:"ledger"`
+	Tenant     string            `json:"tenant,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	HostWallet string            `json:"host_wallet"`
+	Height     int               `json:"height"`
+	LastHash   string            `json:"last_hash"`
+	Chain      []Block           `json:"chain"`
+	Mempool    []Transaction     `json:"mempool"`
+	NFTs       map[string]string `json:"nfts"`
+	Wallets    []string          `json:"wallets"`
+
+	Users  map[string]UserInfo      `json:"users,omitempty"`
+	Tokens map[string]*TokenDetails `json:"tokens,omitempty"`
+
+	Classes map[string]*NFTClassInfo `json:"classes,omitempty"`
+
+	Masters  map[string]string    `json:"masters,omitempty"`
+	Children map[string]ChildLink `json:"children,omitempty"`
+
+	// WalletHeights is the block whose state root first includes each
+	// wallet. Wallets not listed enter the state at the next block.
+	WalletHeights map[string]int `json:"wallet_heights,omitempty"`
+
+	// Approvals and Proposals are kept off-chain, so they travel in the
+	// snapshot rather than being replayed from transactions.
+	Approvals map[string]*Approval `json:"approvals,omitempty"`
+	Proposals map[string]*Proposal `json:"proposals,omitempty"`
+}
+
+// signedSnapshot is the archive envelope. Snapshot holds the exact bytes that
+// were signed so verification does not depend on re-encoding.
+type signedSnapshot struct {
+	Snapshot  json.RawMessage `json:"snapshot"`
+	Signature string          `json:"signature"`
+}
+
+// ExportSnapshot writes the ledger state to w as a gzip-compressed archive
+// signed by the host key.
+func (bc *Blockchain) ExportSnapshot(w io.Writer) (*Snapshot, error) {
+	bc.mutex.RLock()
+	// Pruned bodies are read back from the archive so the snapshot is complete.
+	chain, err := bc.fullChain()
+	if err != nil {
+		bc.mutex.RUnlock()
+		return nil, err
+	}
+	snap := &Snapshot{
+		Version:    SnapshotVersion,
+		Ledger:     ledgerID,
+		Tenant:     bc.tenant,
+		CreatedAt:  time.Now().UTC(),
+		HostWallet: bc.HostWallet,
+		Height:     len(chain),
+		LastHash:   Hash(chain[len(chain)-1]),
+		Chain:      chain,
+		Mempool:    append([]Transaction(nil), bc.CurrentTransactions...),
+		NFTs:       make(map[string]string, len(bc.NFTs)),
+	}
+	for id, owner := range bc.NFTs {
+		snap.NFTs[id] = owner
+	}
+	bc.walletMutex.RLock()
+	snap.Wallets = make([]string, 0, len(bc.Wallets))
+	for address := range bc.Wallets {
+		snap.Wallets = append(snap.Wallets, address)
+	}
+	bc.walletMutex.RUnlock()
+	if len(bc.users) > 0 {
+		snap.Users = make(map[string]UserInfo, len(bc.users))
+		for address, user := range bc.users {
+			snap.Users[address] = user
+		}
+	}
+	if len(bc.tokens) > 0 {
+		snap.Tokens = make(map[string]*TokenDetails, len(bc.tokens))
+		for id, details := range bc.tokens {
+			copied := *details
+			snap.Tokens[id] = &copied
+		}
+	}
+	if len(bc.masters) > 0 {
+		snap.Masters = make(map[string]string, len(bc.masters))
+		for address, chainCode := range bc.masters {
+			snap.Masters[address] = chainCode
+		}
+	}
+	if len(bc.children) > 0 {
+		snap.Children = make(map[string]ChildLink, len(bc.children))
+		for address, link := range bc.children {
+			snap.Children[address] = link
+		}
+	}
+	if len(bc.walletHeights) > 0 {
+		snap.WalletHeights = make(map[string]int, len(bc.walletHeights))
+		for address, height := range bc.walletHeights {
+			snap.WalletHeights[address] = height
+		}
+	}
+	if len(bc.classes) > 0 {
+		snap.Classes = make(map[string]*NFTClassInfo, len(bc.classes))
+		for id, info := range bc.classes {
+			copied := *info
+			snap.Classes[id] = &copied
+		}
+	}
+	if len(bc.approvals) > 0 {
+		snap.Approvals = make(map[string]*Approval, len(bc.approvals))
+		for id, a := range bc.approvals {
+			copied := *a
+			snap.Approvals[id] = &copied
+		}
+	}
+	if len(bc.proposals) > 0 {
+		snap.Proposals = make(map[string]*Proposal, len(bc.proposals))
+		for id, p := range bc.proposals {
+			copied := *p
+			copied.Signatures = make(map[string]string, len(p.Signatures))
+			for signer, signature := range p.Signatures {
+				copied.Signatures[signer] = signature
+			}
+			snap.Proposals[id] = &copied
+		}
+	}
+	bc.mutex.RUnlock()
+	sort.Strings(snap.Wallets)
+
+	payload, err := json.Marshal(snap)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := bc.hostSign(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(signedSnapshot{Snapshot: payload, Signature: signature}); err != nil {
+		return nil, err
+	}
+	return snap, zw.Close()
+}
+
+// ImportSnapshot replaces the state of a fresh node with the archive read
+// from r. The signature must come from the node's own host key or from one
+// of trustedHosts, and the chain and ownership state must verify.
+func (bc *Blockchain) ImportSnapshot(r io.Reader, trustedHosts []string) (*Snapshot, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot is not a gzip archive: %w", err)
+	}
+	defer zr.Close()
+
+	var envelope signedSnapshot
+	if err := json.NewDecoder(zr).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(envelope.Snapshot, &snap); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot payload: %w", err)
+	}
+
+	if snap.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+	if snap.Ledger != ledgerID {
+		return nil, fmt.Errorf("snapshot is for ledger %q, this node is %q", snap.Ledger, ledgerID)
+	}
+	if snap.Tenant != bc.tenant {
+		return nil, fmt.Errorf("snapshot is for tenant %q, not %q", snap.Tenant, bc.tenant)
+	}
+	bc.mutex.RLock()
+	trustedHosts = append(trustedHosts, bc.HostWallet)
+	bc.mutex.RUnlock()
+	if err := verifySnapshotSignature(&snap, envelope, trustedHosts); err != nil {
+		return nil, err
+	}
+	if err := verifyChain(snap.Chain); err != nil {
+		return nil, err
+	}
+	// A node with a genesis file only takes a chain that starts from it.
+	if genesis != nil {
+		if local, remote := bc.GenesisHash(), Hash(snap.Chain[0]); remote != local {
+			return nil, fmt.Errorf("snapshot genesis %s does not match this node's genesis %s", remote, local)
+		}
+	}
+	if snap.Height != len(snap.Chain) || snap.LastHash != Hash(snap.Chain[len(snap.Chain)-1]) {
+		return nil, errors.New("snapshot metadata does not match its chain")
+	}
+	if err := verifyOwnership(&snap); err != nil {
+		return nil, err
+	}
+	states, err := buildStates(snap.Chain, snap.WalletHeights)
+	if err != nil {
+		return nil, err
+	}
+
+	wallets := make(map[string]*ecdsa.PublicKey, len(snap.Wallets))
+	for _, address := range snap.Wallets {
+		pub, err := publicKeyFromAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("wallet %s: %w", address, err)
+		}
+		wallets[address] = pub
+	}
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	if len(bc.Chain) > 1 || len(bc.NFTs) > 0 || len(bc.CurrentTransactions) > 0 {
+		return nil, errors.New("snapshots can only be imported into a fresh node")
+	}
+
+	// Keep this node's host wallet registered alongside the imported ones.
+	bc.walletMutex.Lock()
+	defer bc.walletMutex.Unlock()
+	if bc.HostWallet != "" {
+		wallets[bc.HostWallet] = bc.Wallets[bc.HostWallet]
+	}
+	bc.Chain = snap.Chain
+	bc.states = states
+	bc.walletHeights = make(map[string]int, len(snap.WalletHeights))
+	for address, height := range snap.WalletHeights {
+		bc.walletHeights[address] = height
+	}
+	bc.CurrentTransactions = snap.Mempool
+	bc.NFTs = snap.NFTs
+	bc.Wallets = wallets
+	if snap.Users != nil {
+		bc.users = snap.Users
+	}
+	if snap.Tokens != nil {
+		bc.tokens = snap.Tokens
+	}
+	if snap.Classes != nil {
+		bc.classes = snap.Classes
+	}
+	if snap.Masters != nil {
+		bc.masters = snap.Masters
+	}
+	if snap.Children != nil {
+		bc.children = snap.Children
+	}
+	if snap.Approvals != nil {
+		bc.approvals = snap.Approvals
+	}
+	if snap.Proposals != nil {
+		bc.proposals = snap.Proposals
+		// A proposal exported mid-execution would never finish here, and
+		// whether it took effect is in the chain, not the proposal.
+		for _, p := range bc.proposals {
+			if p.Status == proposalExecuting {
+				p.Status = proposalFailed
+				p.Error = "interrupted by snapshot export"
+			}
+		}
+	}
+	bc.nftIndex = make(map[string][]int)
+	bc.txIndex = make(map[string]sealedTx)
+	bc.burned = make(map[string]bool)
+	for _, block := range bc.Chain {
+		bc.indexBlock(block)
+		for _, tx := range block.Transactions {
+			if tx.Type == "burn" {
+				bc.burned[tx.NFTId] = true
+			}
+		}
+	}
+	for _, tx := range bc.CurrentTransactions {
+		if tx.Type == "burn" {
+			bc.burned[tx.NFTId] = true
+		}
+	}
+	// Envelope nonces are recovered from the transactions that carry them.
+	bc.nonces = make(map[string]uint64)
+	for _, block := range bc.Chain {
+		for _, tx := range block.Transactions {
+			if tx.Nonce > bc.nonces[tx.Sender] {
+				bc.nonces[tx.Sender] = tx.Nonce
+			}
+		}
+	}
+	for _, tx := range bc.CurrentTransactions {
+		if tx.Nonce > bc.nonces[tx.Sender] {
+			bc.nonces[tx.Sender] = tx.Nonce
+		}
+	}
+	// AuthNFT burn links are recovered from the mints that carry them.
+	bc.authBurns = make(map[string]string)
+	bc.origins = make(map[string]*BurnProof)
+	for _, block := range bc.Chain {
+		for _, tx := range block.Transactions {
+			bc.linkAuthBurn(tx)
+		}
+	}
+	for _, tx := range bc.CurrentTransactions {
+		bc.linkAuthBurn(tx)
+	}
+	// Freezes are replayed in order, so unfreezes lift them again.
+	bc.frozenNFTs = make(map[string]FreezeRecord)
+	bc.frozenWallets = make(map[string]FreezeRecord)
+	bc.freezeLog = nil
+	for _, block := range bc.Chain {
+		for _, tx := range block.Transactions {
+			if tx.Freeze != nil {
+				bc.applyFreeze(tx)
+			}
+		}
+	}
+	for _, tx := range bc.CurrentTransactions {
+		if tx.Freeze != nil {
+			bc.applyFreeze(tx)
+		}
+	}
+	// Peer anchors are recovered from their transactions. The peer may hold
+	// anchors of a chain this snapshot replaced, so the next tip is anchored
+	// again.
+	bc.anchors = nil
+	bc.anchored = 0
+	for _, block := range bc.Chain {
+		for _, tx := range block.Transactions {
+			if tx.Anchor != nil {
+				bc.anchors = append(bc.anchors, tx)
+			}
+		}
+	}
+	for _, tx := range bc.CurrentTransactions {
+		if tx.Anchor != nil {
+			bc.anchors = append(bc.anchors, tx)
+		}
+	}
+	for id, details := range bc.tokens {
+		if !details.Active {
+			bc.burned[id] = true
+		}
+	}
+	// Session times are not part of the snapshot, so imported NFTs start
+	// their TTL and idle clocks now. Burned NFTs and NFTs a host wallet
+	// holds are already retired, so the reaper must not retire them again.
+	now := time.Now()
+	bc.activity = make(map[string]*nftActivity, len(bc.NFTs))
+	for id, owner := range bc.NFTs {
+		if bc.burned[id] || owner == snap.HostWallet || owner == bc.HostWallet {
+			continue
+		}
+		bc.activity[id] = newActivity(now)
+	}
+	if err := bc.prune(); err != nil {
+		return nil, err
+	}
+	bc.publishView()
+	return &snap, nil
+}
+
+func verifySnapshotSignature(snap *Snapshot, envelope signedSnapshot, trustedHosts []string) error {
+	trusted := false
+	for _, host := range trustedHosts {
+		if host == snap.HostWallet {
+			trusted = true
+		}
+	}
+	if !trusted {
+		return fmt.Errorf("snapshot signer %s is not a trusted host", snap.HostWallet)
+	}
+
+	pub, err := publicKeyFromAddress(snap.HostWallet)
+	if err != nil {
+		return fmt.Errorf("snapshot host wallet: %w", err)
+	}
+	sig, err := hex.DecodeString(envelope.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot signature encoding: %w", err)
+	}
+	hash := sha256.Sum256(envelope.Snapshot)
+	if !ecdsa.VerifyASN1(pub, hash[:], sig) {
+		return errors.New("snapshot signature does not verify")
+	}
+	return nil
+}
+
+// verifyChain checks block numbering, hash links, transaction roots and
+// proofs of work.
+func verifyChain(chain []Block) error {
+	if len(chain) == 0 {
+		return errors.New("chain is empty")
+	}
+	for i := 1; i < len(chain); i++ {
+		prev, block := chain[i-1], chain[i]
+		if block.Index != prev.Index+1 {
+			return fmt.Errorf("block %d: expected index %d", block.Index, prev.Index+1)
+		}
+		if block.Pruned || TxRoot(block.Transactions) != block.TxRoot || len(block.Transactions) != block.TxCount {
+			return fmt.Errorf("block %d: body does not match its transaction root", block.Index)
+		}
+		if block.PreviousHash != Hash(prev) {
+			return fmt.Errorf("block %d: previous hash does not match block %d", block.Index, prev.Index)
+		}
+		if !blockchain.ValidProof(prev.Proof, block.Proof) {
+			return fmt.Errorf("block %d: invalid proof of work", block.Index)
+		}
+	}
+	return nil
+}
+
+// verifyOwnership replays every sealed and pending transaction and checks the
+// result against the snapshot's ownership table.
+func verifyOwnership(snap *Snapshot) error {
+	owners := make(map[string]string)
+	apply := func(tx Transaction) {
+		if tx.movesNFT() {
+			owners[tx.NFTId] = tx.Recipient
+		}
+	}
+	for _, block := range snap.Chain {
+		for _, tx := range block.Transactions {
+			apply(tx)
+		}
+	}
+	for _, tx := range snap.Mempool {
+		apply(tx)
+	}
+
+	if len(owners) != len(snap.NFTs) {
+		return fmt.Errorf("ownership table has %d NFTs, transactions produce %d", len(snap.NFTs), len(owners))
+	}
+	for id, owner := range owners {
+		if snap.NFTs[id] != owner {
+			return fmt.Errorf("NFT %s: ownership table does not match transaction history", id)
+		}
+	}
+	return nil
+}
+
+// SnapshotHandler serves a signed snapshot of the ledger.
+func SnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var archive bytes.Buffer
+	snap, err := ledgerFor(r).ExportSnapshot(&archive)
+	if err != nil {
+		log.Error("Failed to export snapshot", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	name := ledgerID
+	if snap.Tenant != "" {
+		name += "-" + snap.Tenant
+	}
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%s-snapshot-%d.json.gz", name, snap.CreatedAt.Unix()))
+	w.Write(archive.Bytes())
+	log.Info("Snapshot exported", "tenant", snap.Tenant, "height", snap.Height, "nfts", len(snap.NFTs), "wallets", len(snap.Wallets))
+}
+
+// ImportSnapshotFile imports the archive at path into the default ledger.
+// TRUSTED_SNAPSHOT_HOSTS lists additional host wallets whose snapshots are
+// accepted, separated by commas.
+func ImportSnapshotFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var trusted []string
+	for _, host := range strings.Split(os.Getenv("TRUSTED_SNAPSHOT_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			trusted = append(trusted, host)
+		}
+	}
+
+	snap, err := blockchain.ImportSnapshot(f, trusted)
+	if err != nil {
+		return err
+	}
+	log.Info("Snapshot imported", "path", path, "height", snap.Height, "nfts", len(snap.NFTs),
+		"wallets", len(snap.Wallets), "created_at", snap.CreatedAt)
+	return nil
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+)
+
+// Locking model
+//
+// bc.mutex is a read-write lock over the ledger maps, the mempool and the
+// chain. Validation, owner lookups and the explorer take the read lock, so
+// any number of them run in parallel; only mutations take the write lock.
+// Touching an NFT's activity on a successful validation is atomic and safe
+// under the read lock.
+//
+// Wallets has its own lock, bc.walletMutex, because signature checks run both
+// with and without bc.mutex held. It is always taken after bc.mutex.
+//
+// bc.mineMutex serializes miners. Proof of work runs with no ledger lock
+// held; the write lock is only taken to append the sealed block.
+//
+// Readers that list blocks use bc.view, an immutable chain summary that is
+// replaced, never modified, whenever the chain changes.
+
+// chainView is a copy-on-write summary of the chain.
+type chainView struct {
+	blocks []BlockSummary // Oldest first
+}
+
+// summarize returns the explorer summary of block.
+func summarize(block Block) BlockSummary {
+	return BlockSummary{
+		Index:        block.Index,
+		Timestamp:    block.Timestamp,
+		Hash:         Hash(block),
+		PreviousHash: block.PreviousHash,
+		TxCount:      block.TxCount,
+		Pruned:       block.Pruned,
+	}
+}
+
+// publishView replaces the chain view after the chain has changed. Blocks
+// already in the previous view keep their hash; only new blocks are hashed.
+// Callers must hold bc.mutex for writing.
+func (bc *Blockchain) publishView() {
+	var blocks []BlockSummary
+	if old := bc.view.Load(); old != nil && len(old.blocks) <= len(bc.Chain) &&
+		(len(old.blocks) == 0 || old.blocks[0].Hash == Hash(bc.Chain[0])) {
+		blocks = make([]BlockSummary, len(old.blocks), len(bc.Chain))
+		copy(blocks, old.blocks)
+	} else {
+		blocks = make([]BlockSummary, 0, len(bc.Chain))
+	}
+	for i := range blocks {
+		blocks[i].Pruned = bc.Chain[i].Pruned
+	}
+	for _, block := range bc.Chain[len(blocks):] {
+		blocks = append(blocks, summarize(block))
+	}
+	bc.view.Store(&chainView{blocks: blocks})
+}
+
+// wallet returns the public key registered for address.
+func (bc *Blockchain) wallet(address string) (*ecdsa.PublicKey, bool) {
+	bc.walletMutex.RLock()
+	defer bc.walletMutex.RUnlock()
+	key, ok := bc.Wallets[address]
+	return key, ok
+}
+
+// registerWallet registers a new user wallet, enforcing the wallet quota.
+func (bc *Blockchain) registerWallet(address string, key *ecdsa.PublicKey) error {
+	bc.walletMutex.Lock()
+	defer bc.walletMutex.Unlock()
+	if bc.maxWallets > 0 && len(bc.Wallets)-1 >= bc.maxWallets {
+		return ErrWalletQuota
+	}
+	bc.Wallets[address] = key
+	return nil
+}
+
+// liveNFTs counts the NFTs not yet returned to the host wallet. Callers must
+// hold bc.mutex.
+func (bc *Blockchain) liveNFTs() int {
+	live := 0
+	for _, owner := range bc.NFTs {
+		if owner != bc.HostWallet {
+			live++
+		}
+	}
+	return live
+}
+
+// addWallet registers the public key for address.
+func (bc *Blockchain) addWallet(address string, key *ecdsa.PublicKey) {
+	bc.walletMutex.Lock()
+	defer bc.walletMutex.Unlock()
+	bc.Wallets[address] = key
+}
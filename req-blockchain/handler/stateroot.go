@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// State root
+//
+// Every block header commits to a state root: the root of a sparse Merkle
+// tree over NFT ownership and the wallet registry as of that block. Leaves
+// are keyed by sha256("nft:" + id) or sha256("wallet:" + address) and walked
+// most significant bit first. A subtree holding a single leaf is stored as
+// that leaf, so paths are only as deep as needed to separate keys:
+//
+//	empty    = 32 zero bytes
+//	leaf     = sha256(0x00 || key || sha256(json(StateLeaf)))
+//	internal = sha256(0x01 || left || right)
+//
+// NFT ownership is replayed from sealed transactions only; pending
+// transactions are not part of any state root until they are mined. A
+// wallet enters the state in the first block mined after it is registered.
+//
+// Trees are immutable and share unchanged subtrees, so the state after
+// every block is kept to answer proofs against older blocks.
+
+// StateLeaf is the value stored in the state tree for one NFT or wallet.
+type StateLeaf struct {
+	Kind   string `json:"kind"` // "nft" or "wallet"
+	ID     string `json:"id"`   // NFT ID or wallet address
+	Owner  string `json:"owner,omitempty"`
+	Burned bool   `json:"burned,omitempty"`
+}
+
+// smtNode is a node of the state tree. Leaves have leaf set; internal nodes
+// have at least one child.
+type smtNode struct {
+	hash        [32]byte
+	left, right *smtNode
+	key         [32]byte
+	leaf        *StateLeaf
+}
+
+// nftStateKey and walletStateKey are the tree keys of an NFT and a wallet.
+func nftStateKey(nftId string) [32]byte {
+	return sha256.Sum256([]byte("nft:" + nftId))
+}
+
+func walletStateKey(address string) [32]byte {
+	return sha256.Sum256([]byte("wallet:" + address))
+}
+
+func keyBit(key [32]byte, depth int) byte {
+	return (key[depth/8] >> (7 - uint(depth%8))) & 1
+}
+
+func nodeHash(n *smtNode) [32]byte {
+	if n == nil {
+		return [32]byte{}
+	}
+	return n.hash
+}
+
+func newStateLeaf(key [32]byte, leaf *StateLeaf) *smtNode {
+	value, _ := json.Marshal(leaf)
+	valueHash := sha256.Sum256(value)
+	return &smtNode{hash: sha256.Sum256(append(append([]byte{0}, key[:]...), valueHash[:]...)), key: key, leaf: leaf}
+}
+
+func newStateBranch(left, right *smtNode) *smtNode {
+	l, r := nodeHash(left), nodeHash(right)
+	return &smtNode{hash: sha256.Sum256(append(append([]byte{1}, l[:]...), r[:]...)), left: left, right: right}
+}
+
+// stateInsert returns a copy of the tree at n with leaf set, replacing any
+// leaf with the same key. n is not modified.
+func stateInsert(n, leaf *smtNode, depth int) *smtNode {
+	switch {
+	case n == nil:
+		return leaf
+	case n.leaf != nil && n.key == leaf.key:
+		return leaf
+	case n.leaf != nil:
+		return stateSplit(n, leaf, depth)
+	case keyBit(leaf.key, depth) == 0:
+		return newStateBranch(stateInsert(n.left, leaf, depth+1), n.right)
+	default:
+		return newStateBranch(n.left, stateInsert(n.right, leaf, depth+1))
+	}
+}
+
+// stateSplit joins two leaves under branches down to the first bit where
+// their keys differ.
+func stateSplit(a, b *smtNode, depth int) *smtNode {
+	bitA, bitB := keyBit(a.key, depth), keyBit(b.key, depth)
+	switch {
+	case bitA != bitB && bitA == 0:
+		return newStateBranch(a, b)
+	case bitA != bitB:
+		return newStateBranch(b, a)
+	case bitA == 0:
+		return newStateBranch(stateSplit(a, b, depth+1), nil)
+	default:
+		return newStateBranch(nil, stateSplit(a, b, depth+1))
+	}
+}
+
+// stateGet returns the leaf stored under key, or nil.
+func stateGet(n *smtNode, key [32]byte) *StateLeaf {
+	for depth := 0; n != nil; depth++ {
+		if n.leaf != nil {
+			if n.key == key {
+				return n.leaf
+			}
+			return nil
+		}
+		if keyBit(key, depth) == 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return nil
+}
+
+// nextState applies a block's transactions and newly registered wallets to
+// the state tree of the previous block.
+func nextState(prev *smtNode, transactions []Transaction, wallets []string) *smtNode {
+	state := prev
+	for _, tx := range transactions {
+		if !tx.movesNFT() {
+			continue
+		}
+		key := nftStateKey(tx.NFTId)
+		burned := tx.Type == "burn"
+		if old := stateGet(state, key); old != nil {
+			burned = burned || old.Burned
+		}
+		state = stateInsert(state, newStateLeaf(key, &StateLeaf{Kind: "nft", ID: tx.NFTId, Owner: tx.Recipient, Burned: burned}), 0)
+	}
+	for _, address := range wallets {
+		key := walletStateKey(address)
+		state = stateInsert(state, newStateLeaf(key, &StateLeaf{Kind: "wallet", ID: address}), 0)
+	}
+	return state
+}
+
+// stateRoot returns the hex root hash of a state tree.
+func stateRoot(n *smtNode) string {
+	hash := nodeHash(n)
+	return hex.EncodeToString(hash[:])
+}
+
+// newStateWallets returns the registered wallets that are not yet in the
+// state, sorted, and records height as the block that adds them. Callers
+// must hold bc.mutex for writing.
+func (bc *Blockchain) newStateWallets(height int) []string {
+	bc.walletMutex.RLock()
+	defer bc.walletMutex.RUnlock()
+
+	var added []string
+	for address := range bc.Wallets {
+		if _, ok := bc.walletHeights[address]; !ok {
+			bc.walletHeights[address] = height
+			added = append(added, address)
+		}
+	}
+	sort.Strings(added)
+	return added
+}
+
+// buildStates replays chain into the state tree after every block, placing
+// each wallet at the height recorded for it. Blocks that carry a state root
+// must match the replayed state.
+func buildStates(chain []Block, walletHeights map[string]int) ([]*smtNode, error) {
+	byHeight := make(map[int][]string)
+	for address, height := range walletHeights {
+		byHeight[height] = append(byHeight[height], address)
+	}
+
+	states := make([]*smtNode, len(chain))
+	var prev *smtNode
+	for i, block := range chain {
+		prev = nextState(prev, block.Transactions, byHeight[block.Index])
+		if block.StateRoot != "" && stateRoot(prev) != block.StateRoot {
+			return nil, fmt.Errorf("block %d: state does not match its state root", block.Index)
+		}
+		states[i] = prev
+	}
+	return states, nil
+}
+
+// StateProof shows that an NFT or wallet has a given value, or is absent,
+// in the state committed to by a block header.
+type StateProof struct {
+	Block     int         `json:"block"`
+	BlockHash string      `json:"block_hash"`
+	Header    BlockHeader `json:"header"`
+	Key       string      `json:"key"`
+	// Leaf is the value under Key, or nil when Key is absent.
+	Leaf *StateLeaf `json:"leaf,omitempty"`
+	// Other is the different leaf found on Key's path, if any, when Key is
+	// absent.
+	Other *ProofLeaf `json:"other,omitempty"`
+	// Siblings are the sibling hashes on Key's path, root first.
+	Siblings []string `json:"siblings"`
+}
+
+// ProofLeaf is a leaf given by key and value hash in a non-existence proof.
+type ProofLeaf struct {
+	Key       string `json:"key"`
+	ValueHash string `json:"value_hash"`
+}
+
+// proveState builds the proof for key against the state tree root.
+func proveState(root *smtNode, key [32]byte) *StateProof {
+	proof := &StateProof{Key: hex.EncodeToString(key[:]), Siblings: []string{}}
+	n := root
+	for depth := 0; n != nil && n.leaf == nil; depth++ {
+		sibling := n.left
+		if keyBit(key, depth) == 0 {
+			sibling, n = n.right, n.left
+		} else {
+			n = n.right
+		}
+		hash := nodeHash(sibling)
+		proof.Siblings = append(proof.Siblings, hex.EncodeToString(hash[:]))
+	}
+	switch {
+	case n == nil:
+	case n.key == key:
+		proof.Leaf = n.leaf
+	default:
+		value, _ := json.Marshal(n.leaf)
+		valueHash := sha256.Sum256(value)
+		proof.Other = &ProofLeaf{Key: hex.EncodeToString(n.key[:]), ValueHash: hex.EncodeToString(valueHash[:])}
+	}
+	return proof
+}
+
+// StateProofAt returns the proof for key against block index, or the latest
+// block when index is 0.
+func (bc *Blockchain) StateProofAt(key [32]byte, index int) (*StateProof, error) {
+	bc.mutex.RLock()
+	if index == 0 {
+		index = len(bc.Chain)
+	}
+	if index < 1 || index > len(bc.Chain) {
+		bc.mutex.RUnlock()
+		return nil, fmt.Errorf("block %d does not exist", index)
+	}
+	block := bc.Chain[index-1]
+	state := bc.states[index-1]
+	bc.mutex.RUnlock()
+
+	if block.StateRoot == "" {
+		return nil, fmt.Errorf("block %d has no state root", index)
+	}
+	proof := proveState(state, key)
+	proof.Block = index
+	proof.BlockHash = Hash(block)
+	proof.Header = block.Header()
+	return proof, nil
+}
+
+// StateProofHandler returns an ownership or non-existence proof for the NFT
+// in nft_id, or the wallet in wallet, against block (default: latest).
+func StateProofHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	var key [32]byte
+	switch {
+	case query.Get("nft_id") != "":
+		key = nftStateKey(query.Get("nft_id"))
+	case query.Get("wallet") != "":
+		key = walletStateKey(query.Get("wallet"))
+	default:
+		http.Error(w, "Missing nft_id or wallet", http.StatusBadRequest)
+		return
+	}
+	index := 0
+	if s := query.Get("block"); s != "" {
+		var err error
+		if index, err = strconv.Atoi(s); err != nil || index < 1 {
+			http.Error(w, "Invalid block", http.StatusBadRequest)
+			return
+		}
+	}
+
+	proof, err := ledgerFor(r).StateProofAt(key, index)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(proof)
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TenantHeader selects a tenant namespace. Requests can instead use a path
+// prefix: /t/{tenant}/authnft/create is /authnft/create on tenant {tenant}.
+const TenantHeader = "X-Tenant-ID"
+
+// TenantConfig describes one tenant namespace. Every tenant has its own
+// chain, host wallet, wallet registry and NFT ID space.
+type TenantConfig struct {
+	ID          string `json:"id"`
+	HostKeyFile string `json:"host_key_file"` // Empty for an ephemeral host key
+	MaxNFTs     int    `json:"max_nfts"`      // Live NFTs; 0 is unlimited
+	MaxWallets  int    `json:"max_wallets"`   // 0 is unlimited
+}
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// tenants maps tenant IDs to their ledgers. The default ledger is not in it.
+var tenants = struct {
+	sync.RWMutex
+	byID map[string]*Blockchain
+}{byID: make(map[string]*Blockchain)}
+
+// AddTenant creates a tenant ledger with its own genesis block and host
+// wallet, and returns the host wallet address.
+func AddTenant(config TenantConfig) (string, error) {
+	if !tenantIDPattern.MatchString(config.ID) {
+		return "", fmt.Errorf("invalid tenant id %q", config.ID)
+	}
+	if config.MaxNFTs < 0 || config.MaxWallets < 0 {
+		return "", fmt.Errorf("tenant %s: quotas cannot be negative", config.ID)
+	}
+
+	bc := NewBlockchain()
+	bc.tenant = config.ID
+	bc.maxNFTs = config.MaxNFTs
+	bc.maxWallets = config.MaxWallets
+	bc.CreateGenesisBlock()
+	address, err := bc.initHostKey(config.HostKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("tenant %s: %w", config.ID, err)
+	}
+
+	tenants.Lock()
+	defer tenants.Unlock()
+	if _, exists := tenants.byID[config.ID]; exists {
+		return "", fmt.Errorf("tenant %s is already defined", config.ID)
+	}
+	tenants.byID[config.ID] = bc
+	return address, nil
+}
+
+// LoadTenants adds the tenants listed in the JSON file at path, an array of
+// TenantConfig objects.
+func LoadTenants(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read tenants file: %w", err)
+	}
+	var configs []TenantConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return fmt.Errorf("failed to parse tenants file: %w", err)
+	}
+	for _, config := range configs {
+		address, err := AddTenant(config)
+		if err != nil {
+			return err
+		}
+		log.Info("Tenant ready", "tenant", config.ID, "host_wallet", address,
+			"max_nfts", config.MaxNFTs, "max_wallets", config.MaxWallets)
+	}
+	return nil
+}
+
+// allLedgers returns the default ledger followed by every tenant ledger in
+// ID order.
+func allLedgers() []*Blockchain {
+	tenants.RLock()
+	defer tenants.RUnlock()
+
+	ids := make([]string, 0, len(tenants.byID))
+	for id := range tenants.byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	ledgers := []*Blockchain{blockchain}
+	for _, id := range ids {
+		ledgers = append(ledgers, tenants.byID[id])
+	}
+	return ledgers
+}
+
+type tenantKey struct{}
+
+// ledgerFor returns the ledger WithTenant selected for r, or the default
+// ledger when the request named no tenant.
+func ledgerFor(r *http.Request) *Blockchain {
+	if bc, ok := r.Context().Value(tenantKey{}).(*Blockchain); ok {
+		return bc
+	}
+	return blockchain
+}
+
+// WithTenant routes each request to a tenant ledger named by TenantHeader or
+// a /t/{tenant}/ path prefix, which is stripped before next sees the request.
+// Requests naming neither use the default ledger. Unknown tenants get 404.
+func WithTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(TenantHeader)
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/t/"); ok {
+			pathID, path, _ := strings.Cut(rest, "/")
+			if id != "" && id != pathID {
+				writeError(w, fmt.Errorf("tenant %q in path does not match %s %q", pathID, TenantHeader, id))
+				return
+			}
+			id = pathID
+			r = r.Clone(r.Context())
+			r.URL.Path = "/" + path
+			r.URL.RawPath = ""
+		}
+		if id == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenants.RLock()
+		bc, ok := tenants.byID[id]
+		tenants.RUnlock()
+		if !ok {
+			writeError(w, &APIError{http.StatusNotFound, codeUnknownTenant, fmt.Sprintf("unknown tenant %q", id)})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, bc)))
+	})
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Owner returns the owner of nftId. Burned NFTs report ErrBurned.
+func (bc *Blockchain) Owner(nftId string) (string, error) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	owner, exists := bc.NFTs[nftId]
+	if !exists {
+		return "", ErrNFTNotFound
+	}
+	if bc.burned[nftId] {
+		return "", ErrBurned
+	}
+	return owner, nil
+}
+
+// ValidateOwner checks that address, or the master of a child address,
+// owns nftId with at least minConfirmations and, if so, records the use for the session reaper.
+func (bc *Blockchain) ValidateOwner(nftId, address string, minConfirmations int) (Finality, error) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	owner, exists := bc.NFTs[nftId]
+	if !exists {
+		validationFailures.Inc("nft_not_found")
+		return Finality{}, ErrNFTNotFound
+	}
+	if bc.burned[nftId] {
+		validationFailures.Inc("burned")
+		return Finality{}, ErrBurned
+	}
+	if bc.expired(nftId, time.Now()) {
+		validationFailures.Inc("expired")
+		return Finality{}, ErrExpired
+	}
+	if !bc.ownedBy(owner, address) {
+		validationFailures.Inc("not_owner")
+		return Finality{}, ErrNotOwner
+	}
+	if err := bc.frozen(nftId, owner, address); err != nil {
+		validationFailures.Inc("frozen")
+		return Finality{}, err
+	}
+	finality := bc.finality(nftId)
+	if err := finality.confirmed(minConfirmations); err != nil {
+		validationFailures.Inc("unconfirmed")
+		return finality, err
+	}
+	bc.touchNFT(nftId)
+	return finality, nil
+}
+
+// decodeV1 decodes a /v1 request body into v, rejecting unknown fields.
+func decodeV1(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// requireFields takes name, value pairs and reports the names whose values
+// are empty.
+func requireFields(pairs ...string) error {
+	var missing []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			missing = append(missing, pairs[i])
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// V1CreateHandler mints an NFT: POST {owner, username?, user_type?,
+// token_type?, class?, metadata?}.
+func V1CreateHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Owner     string            `json:"owner"`
+		Username  string            `json:"username"`
+		UserType  string            `json:"user_type"`
+		TokenType string            `json:"token_type"`
+		Class     string            `json:"class"`
+		Metadata  map[string]string `json:"metadata"`
+		AuthBurn  *BurnProof        `json:"auth_burn"`
+	}
+	if err := decodeV1(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := requireFields("owner", req.Owner); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := checkMintAllowed(r, req.Class); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var details *TokenDetails
+	if req.Username != "" || req.UserType != "" || req.TokenType != "" {
+		details = &TokenDetails{Username: req.Username, UserType: req.UserType, TokenType: req.TokenType}
+	}
+
+	nftId := fmt.Sprintf("nft-%d", time.Now().UnixNano())
+	receipt, err := ledgerFor(r).CreateNFT(req.Owner, nftId, req.AuthBurn, details, req.Class, req.Metadata)
+	if err != nil {
+		log.Error("Failed to create NFT", "owner", req.Owner, "error", err)
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"nft_id": nftId, "owner": req.Owner, "receipt": receipt})
+}
+
+// V1OwnerHandler returns the owner of an NFT: GET ?nft_id=.
+func V1OwnerHandler(w http.ResponseWriter, r *http.Request) {
+	nftId := r.URL.Query().Get("nft_id")
+	if err := requireFields("nft_id", nftId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	owner, err := ledgerFor(r).Owner(nftId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"nft_id": nftId, "owner": owner})
+}
+
+// V1ValidateHandler checks ownership: POST {nft_id, address,
+// min_confirmations?}. Unlike the unversioned endpoint, a failed check is an
+// error response, not valid:false.
+func V1ValidateHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		NFTId            string `json:"nft_id"`
+		Address          string `json:"address"`
+		MinConfirmations int    `json:"min_confirmations"`
+	}
+	if err := decodeV1(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := requireFields("nft_id", req.NFTId, "address", req.Address); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	finality, err := ledgerFor(r).ValidateOwner(req.NFTId, req.Address, req.MinConfirmations)
+	if err != nil {
+		log.Error("Ownership validation failed", "nft_id", req.NFTId, "address", req.Address, "error", err)
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Valid bool `json:"valid"`
+		Finality
+	}{true, finality})
+}
+
+// V1TransferHandler moves an NFT: POST {sender, nft_id, signature,
+// recipient?}, signed over the recipient address and NFT ID. The recipient
+// defaults to the host wallet.
+func V1TransferHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Sender    string `json:"sender"`
+		NFTId     string `json:"nft_id"`
+		Signature string `json:"signature"`
+		Recipient string `json:"recipient"`
+	}
+	if err := decodeV1(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := requireFields("sender", req.Sender, "nft_id", req.NFTId, "signature", req.Signature); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	bc := ledgerFor(r)
+	if req.Recipient == "" {
+		req.Recipient = bc.HostWallet
+	}
+	receipt, err := bc.TransferNFTTo(req.Sender, req.Recipient, req.NFTId, req.Signature)
+	if err != nil {
+		log.Error("Failed to transfer NFT", "nft_id", req.NFTId, "error", err)
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"nft_id": req.NFTId, "owner": req.Recipient, "receipt": receipt})
+}
+
+// V1BurnHandler burns an NFT: POST {sender, nft_id, signature}, signed over
+// the NFT ID followed by "burn".
+func V1BurnHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Sender    string `json:"sender"`
+		NFTId     string `json:"nft_id"`
+		Signature string `json:"signature"`
+	}
+	if err := decodeV1(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := requireFields("sender", req.Sender, "nft_id", req.NFTId, "signature", req.Signature); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	receipt, err := ledgerFor(r).BurnNFT(req.Sender, req.NFTId, req.Signature)
+	if err != nil {
+		log.Error("Failed to burn NFT", "nft_id", req.NFTId, "error", err)
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"nft_id": req.NFTId, "burned": true, "receipt": receipt})
+}
+
+// RegisterV1 adds the /v1 routes under nftPrefix (for example "/v1/authnft")
+// using handle, which main uses to instrument every route.
+func RegisterV1(handle func(pattern string, h http.HandlerFunc), nftPrefix string) {
+	handle(nftPrefix+"/create", allowMethod(http.MethodPost, V1CreateHandler))
+	handle(nftPrefix+"/owner", allowMethod(http.MethodGet, V1OwnerHandler))
+	handle(nftPrefix+"/validate", allowMethod(http.MethodPost, V1ValidateHandler))
+	handle(nftPrefix+"/transfer", allowMethod(http.MethodPost, V1TransferHandler))
+	handle(nftPrefix+"/burn", allowMethod(http.MethodPost, V1BurnHandler))
+	handle("/v1/openapi.json", allowMethod(http.MethodGet, OpenAPIHandler(nftPrefix)))
+}
@@ -3,7 +3,7 @@ package logger
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,6 +33,10 @@ type Config struct {
 	Format     string        // Log format (json, console)
 	BaseDir    string        // Base directory to store log files
 	RotateTime time.Duration // Log rotation interval
+	MaxSize    int64         // Rotate once the active file reaches this many bytes (0 disables)
+	MaxAge     time.Duration // Delete rotated files older than this (0 keeps them)
+	MaxBackups int           // Keep at most this many rotated files (0 keeps all)
+	Compress   bool          // Gzip rotated files
 }
 
 // NewLogger creates a new Logger instance with specified configuration.
@@ -46,12 +50,7 @@ func NewLogger(config Config) (Logger, error) {
 		return nil, fmt.Errorf("failed to create base directory: %w", err)
 	}
 
-	// Create a new log file based on the current date and time
-	logFilePath := getLogFilePath(config.BaseDir)
-	writer, err := getLogWriter(logFilePath, config.RotateTime)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create log writer: %w", err)
-	}
+	writer := zapcore.AddSync(newRotatingFileWriter(config))
 
 	logLevel := getZapLevel(config.Level)
 	encoderConfig := getEncoderConfig(config.Format)
@@ -66,8 +65,17 @@ func NewLogger(config Config) (Logger, error) {
 	return &ZapLogger{delegate: logger.Sugar()}, nil
 }
 
+// NewNop returns a Logger that discards everything. Packages use it until
+// the service injects the real logger at startup.
+func NewNop() Logger {
+	return &ZapLogger{delegate: zap.NewNop().Sugar()}
+}
+
 // NewConfigFromEnv creates Config from environment variables:
-// LOG_LEVEL (default: info), LOG_FORMAT (default: console), BASE_DIR (default: logs)
+// LOG_LEVEL (default: info), LOG_FORMAT (default: console), BASE_DIR (default: logs),
+// LOG_ROTATE_MINUTES (default: 10), LOG_MAX_SIZE_MB (default: 100),
+// LOG_MAX_AGE_DAYS (default: 0, keep all), LOG_MAX_BACKUPS (default: 0, unlimited)
+// and LOG_COMPRESS (default: true)
 func NewConfigFromEnv() Config {
 	level := os.Getenv("LOG_LEVEL")
 	if level == "" {
@@ -88,8 +96,19 @@ func NewConfigFromEnv() Config {
 		Level:      level,
 		Format:     format,
 		BaseDir:    baseDir,
-		RotateTime: 10 * time.Minute,
+		RotateTime: time.Duration(envInt("LOG_ROTATE_MINUTES", 10)) * time.Minute,
+		MaxSize:    int64(envInt("LOG_MAX_SIZE_MB", 100)) << 20,
+		MaxAge:     time.Duration(envInt("LOG_MAX_AGE_DAYS", 0)) * 24 * time.Hour,
+		MaxBackups: envInt("LOG_MAX_BACKUPS", 0),
+		Compress:   os.Getenv("LOG_COMPRESS") != "false",
+	}
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v >= 0 {
+		return v
 	}
+	return def
 }
 
 func getZapLevel(level string) zapcore.Level {
@@ -118,55 +137,6 @@ func getEncoderConfig(format string) zapcore.EncoderConfig {
 	return zap.NewProductionEncoderConfig()
 }
 
-func getLogFilePath(baseDir string) string {
-	date := time.Now().Format("2006-01-02")
-	timePart := time.Now().Format("15-04")
-	dateDir := filepath.Join(baseDir, date)
-	if err := os.MkdirAll(dateDir, os.ModePerm); err != nil {
-		fmt.Printf("failed to create date directory: %v\n", err)
-	}
-	return filepath.Join(dateDir, fmt.Sprintf("log-%s.log", timePart))
-}
-
-func getLogWriter(logFilePath string, rotateTime time.Duration) (zapcore.WriteSyncer, error) {
-	writer := zapcore.AddSync(&rotatingFileWriter{
-		baseDir:    filepath.Dir(filepath.Dir(logFilePath)),
-		rotateTime: rotateTime,
-	})
-	return writer, nil
-}
-
-// rotatingFileWriter handles log rotation based on time intervals.
-type rotatingFileWriter struct {
-	baseDir    string
-	rotateTime time.Duration
-	lastRotate time.Time
-	file       *os.File
-}
-
-func (w *rotatingFileWriter) Write(p []byte) (n int, err error) {
-	now := time.Now()
-	if w.file == nil || now.Sub(w.lastRotate) >= w.rotateTime {
-		if w.file != nil {
-			w.file.Close()
-		}
-		logFilePath := getLogFilePath(w.baseDir)
-		w.file, err = os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			return 0, err
-		}
-		w.lastRotate = now
-	}
-	return w.file.Write(p)
-}
-
-func (w *rotatingFileWriter) Sync() error {
-	if w.file != nil {
-		return w.file.Sync()
-	}
-	return nil
-}
-
 // Debug logs a debug message with structured context.
 func (l *ZapLogger) Debug(msg string, keysAndValues ...interface{}) {
 	l.delegate.Debugw(msg, keysAndValues...)
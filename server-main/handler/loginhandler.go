@@ -2,28 +2,23 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
 
 	"net/http"
 	"nexasecure/logger"
-	"os"
 	"nexasecure/util"
 
 	"github.com/gin-gonic/gin"
 )
 
+// log is the service logger. main injects it with SetLogger at startup.
+var log = logger.NewNop()
 
-func LoginHandler(c *gin.Context) {
-	config := logger.NewConfigFromEnv()
-
-	// Initialize logger
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
+// SetLogger sets the logger used by the handler package.
+func SetLogger(l logger.Logger) {
+	log = l
+}
 
+func LoginHandler(c *gin.Context) {
 	// Get credentials from request
 	var credentials struct {
 		Username string `json:"username"`
@@ -104,16 +99,6 @@ func LoginHandler(c *gin.Context) {
 }
 
 func MFAHandler(c *gin.Context) {
-	config := logger.NewConfigFromEnv()
-
-	// Initialize logger
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	var MFA struct {
 		OTP      string `json:"otp"`
 		Username string `json:"username"`
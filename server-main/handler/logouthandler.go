@@ -2,8 +2,6 @@ package handler
 
 import (
 	"fmt"
-	"nexasecure/logger"
-	"os"
 	"nexasecure/util"
 	"github.com/gin-gonic/gin"
 )
@@ -11,16 +9,6 @@ import (
 
 func LogoutHandler(c *gin.Context) {
 
-	config := logger.NewConfigFromEnv()
-
-	// Initialize logger
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-
 	var UserData struct {
 		Username string `json:"username"`
 	}
@@ -55,30 +43,38 @@ func LogoutHandler(c *gin.Context) {
 	//get reqnft
 	reqnft := util.GetReqNFT(reqpubaddr)
 	if reqnft == "" {
-		log.Error(fmt.Sprintf("Failed to retrieve ReqNFT for user: %s", UserData.Username))
-		c.JSON(500, gin.H{"error": "Failed to retrieve ReqNFT"})
-		return
-	}
-	fmt.Println(reqnft)
-	log.Info(fmt.Sprintf("Req NFT retrieved successfully for user: %s", UserData.Username))
-	//validate reqnft
-	isVerified := util.ValidateReqNFT(reqpubaddr, reqnft)
-	if !isVerified {
-		log.Error(fmt.Sprintf("Req NFT verification failed for user: %s", UserData.Username))
-		c.JSON(400, gin.H{"error": "Req NFT verification failed"})
-		return
-	}
-	log.Info(fmt.Sprintf("Req NFT verified successfully for user: %s", UserData.Username))
-	//sign reqnft
-	//transfer reqnft 
-	//remove reqnft from user
-	isRemoved := util.RemoveReqNFTfromUser(reqpubaddr)
-	if !isRemoved {
-		log.Error(fmt.Sprintf("Failed to remove Req NFT from user: %s", UserData.Username))
-		c.JSON(500, gin.H{"error": "Failed to remove Req NFT"})
-		return
+		// The wallet is unreachable, so retire the session through the
+		// approval the user granted this server at login.
+		if util.BurnReqNFTsAsOperator(reqpubaddr) == 0 {
+			log.Error(fmt.Sprintf("Failed to retrieve ReqNFT for user: %s", UserData.Username))
+			c.JSON(500, gin.H{"error": "Failed to retrieve ReqNFT"})
+			return
+		}
+		log.Warn(fmt.Sprintf("Wallet unavailable, Req NFT retired by server approval for user: %s", UserData.Username))
+	} else {
+		fmt.Println(reqnft)
+		log.Info(fmt.Sprintf("Req NFT retrieved successfully for user: %s", UserData.Username))
+		//validate reqnft
+		isVerified := util.ProveReqNFT(reqpubaddr, reqnft)
+		if !isVerified {
+			log.Error(fmt.Sprintf("Req NFT verification failed for user: %s", UserData.Username))
+			c.JSON(400, gin.H{"error": "Req NFT verification failed"})
+			return
+		}
+		log.Info(fmt.Sprintf("Req NFT verified successfully for user: %s", UserData.Username))
+		//remove reqnft from user
+		isRemoved := util.RemoveReqNFTfromUser(reqpubaddr)
+		if !isRemoved {
+			log.Error(fmt.Sprintf("Failed to remove Req NFT from user: %s", UserData.Username))
+			c.JSON(500, gin.H{"error": "Failed to remove Req NFT"})
+			return
+		}
+		log.Info(fmt.Sprintf("Req NFT removed successfully for user: %s", UserData.Username))
+		//burn reqnft on the ledger, with the wallet if the server holds no approval
+		if util.BurnReqNFTsAsOperator(reqpubaddr) == 0 && !util.BurnReqNFT(reqpubaddr, reqnft) {
+			log.Warn(fmt.Sprintf("Req NFT not burned on the ledger for user: %s", UserData.Username))
+		}
 	}
-	log.Info(fmt.Sprintf("Req NFT removed successfully for user: %s", UserData.Username))
 	//mint new authnft	
 	newAuthNFT := util.MintNewAuthNFT(authpubaddr)
 	if newAuthNFT == "" {
@@ -91,6 +87,7 @@ func LogoutHandler(c *gin.Context) {
 	isStored := util.StoreAuthNFT(authpubaddr,newAuthNFT)
 	if !isStored {
 		log.Error(fmt.Sprintf("Failed to store new Auth NFT for user: %s", UserData.Username))
+		c.JSON(500, gin.H{"error": "Failed to store new Auth NFT"})
 		return
 	}
 	log.Info(fmt.Sprintf("New Auth NFT stored successfully for user: %s", UserData.Username))
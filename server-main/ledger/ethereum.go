@@ -0,0 +1,417 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Contract functions and events called by Ethereum, as declared in
+// AuthToken.sol and RequestToken.sol.
+var (
+	selMintAuthToken   = selector("mintToken(address,string,string,string)")
+	selMintReqToken    = selector("mintToken(address)")
+	selRegisteredUsers = selector("registeredUsers(address)")
+	selBurnToken       = selector("burnToken(uint256)")
+	selOwnerOf         = selector("ownerOf(uint256)")
+	selTransferServer  = selector("transferToServer(uint256)")
+	selVerifyOwner     = selector("verifyTokenOwner(uint256,address)")
+
+	topicTransfer = "0x" + hex.EncodeToString(keccak([]byte("Transfer(address,address,uint256)")))
+)
+
+// Ethereum calls a deployed AuthToken or RequestToken contract through an
+// Ethereum node's JSON-RPC API. Transactions from the server are sent with
+// eth_sendTransaction, so the server account must be unlocked on the node.
+type Ethereum struct {
+	kind     Kind
+	rpcURL   string
+	contract string // 0x contract address
+	server   string // 0x server account
+	client   *http.Client
+	nextID   int64
+
+	// receiptTimeout bounds how long to wait for a transaction to be mined.
+	receiptTimeout time.Duration
+	pollInterval   time.Duration
+}
+
+// NewEthereum returns a client for the token contract at contract.
+func NewEthereum(kind Kind, rpcURL, contract, serverAccount string) (*Ethereum, error) {
+	if rpcURL == "" {
+		return nil, errors.New("Ethereum JSON-RPC URL is required")
+	}
+	contract, err := normalizeAddress(contract)
+	if err != nil {
+		return nil, fmt.Errorf("contract address: %w", err)
+	}
+	server, err := normalizeAddress(serverAccount)
+	if err != nil {
+		return nil, fmt.Errorf("server account: %w", err)
+	}
+	return &Ethereum{
+		kind:           kind,
+		rpcURL:         rpcURL,
+		contract:       contract,
+		server:         server,
+		client:         &http.Client{Timeout: requestTimeout},
+		receiptTimeout: time.Minute,
+		pollInterval:   time.Second,
+	}, nil
+}
+
+func (l *Ethereum) Mint(owner string, details *TokenDetails) (string, error) {
+	recipient, err := normalizeAddress(owner)
+	if err != nil {
+		return "", err
+	}
+
+	var data []byte
+	if l.kind == Auth {
+		if details == nil {
+			// Mint with the details the user was registered with.
+			details, err = l.registeredDetails(recipient)
+			if err != nil {
+				return "", err
+			}
+		}
+		data = encodeCall(selMintAuthToken, abiAddress(recipient),
+			details.Username, details.UserType, details.TokenType)
+	} else {
+		data = encodeCall(selMintReqToken, abiAddress(recipient))
+	}
+
+	receipt, err := l.sendAndWait(data)
+	if err != nil {
+		return "", err
+	}
+	zero := "0x" + strings.Repeat("0", 64)
+	for _, entry := range receipt.Logs {
+		if l.isTransfer(entry) && entry.Topics[1] == zero && topicAddress(entry.Topics[2]) == recipient {
+			return topicUint(entry.Topics[3]).String(), nil
+		}
+	}
+	return "", errors.New("mint transaction has no Transfer event")
+}
+
+// registeredDetails reads the user's registration from AuthToken.sol and
+// returns login token details for it.
+func (l *Ethereum) registeredDetails(user string) (*TokenDetails, error) {
+	out, err := l.call(encodeCall(selRegisteredUsers, abiAddress(user)))
+	if err != nil {
+		return nil, err
+	}
+	exists, err := decodeBool(out, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("user is not registered on AuthToken")
+	}
+	username, err := decodeString(out, 1)
+	if err != nil {
+		return nil, err
+	}
+	userType, err := decodeString(out, 2)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenDetails{Username: username, UserType: userType, TokenType: "login"}, nil
+}
+
+// TransferToServer submits the owner's signed transferToServer transaction
+// and checks that it moved the token to the server account.
+func (l *Ethereum) TransferToServer(owner, nftId, signedTx string) error {
+	from, err := normalizeAddress(owner)
+	if err != nil {
+		return err
+	}
+	tokenId, ok := new(big.Int).SetString(nftId, 10)
+	if !ok {
+		return fmt.Errorf("invalid token ID %q", nftId)
+	}
+
+	var txHash string
+	if err := l.rpc("eth_sendRawTransaction", []interface{}{signedTx}, &txHash); err != nil {
+		return err
+	}
+	receipt, err := l.waitForReceipt(txHash)
+	if err != nil {
+		return err
+	}
+	for _, entry := range receipt.Logs {
+		if l.isTransfer(entry) && topicAddress(entry.Topics[1]) == from &&
+			topicAddress(entry.Topics[2]) == l.server && topicUint(entry.Topics[3]).Cmp(tokenId) == 0 {
+			return nil
+		}
+	}
+	return errors.New("transaction did not transfer the token to the server")
+}
+
+// TransferCall returns the transferToServer call data for nftId, sent to the
+// token contract.
+func (l *Ethereum) TransferCall(nftId string) (string, error) {
+	tokenId, ok := new(big.Int).SetString(nftId, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid token ID %q", nftId)
+	}
+	return "0x" + hex.EncodeToString(encodeCall(selTransferServer, tokenId)), nil
+}
+
+// Burn burns a token the server account holds. owner and signature are
+// not used on Ethereum.
+func (l *Ethereum) Burn(owner, nftId, signature string) error {
+	tokenId, ok := new(big.Int).SetString(nftId, 10)
+	if !ok {
+		return fmt.Errorf("invalid token ID %q", nftId)
+	}
+	_, err := l.sendAndWait(encodeCall(selBurnToken, tokenId))
+	return err
+}
+
+func (l *Ethereum) Owner(nftId string) (string, error) {
+	tokenId, ok := new(big.Int).SetString(nftId, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid token ID %q", nftId)
+	}
+	out, err := l.call(encodeCall(selOwnerOf, tokenId))
+	if err != nil {
+		return "", err
+	}
+	if len(out) < 32 {
+		return "", errors.New("ownerOf returned no address")
+	}
+	return "0x" + hex.EncodeToString(out[12:32]), nil
+}
+
+func (l *Ethereum) Verify(nftId, address string) (bool, error) {
+	tokenId, ok := new(big.Int).SetString(nftId, 10)
+	if !ok {
+		return false, fmt.Errorf("invalid token ID %q", nftId)
+	}
+	claimed, err := normalizeAddress(address)
+	if err != nil {
+		return false, err
+	}
+	out, err := l.call(encodeCall(selVerifyOwner, tokenId, abiAddress(claimed)))
+	if err != nil {
+		return false, err
+	}
+	return decodeBool(out, 0)
+}
+
+func (l *Ethereum) isTransfer(entry rpcLog) bool {
+	return strings.EqualFold(entry.Address, l.contract) && len(entry.Topics) == 4 && entry.Topics[0] == topicTransfer
+}
+
+// JSON-RPC plumbing.
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+type rpcLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+type rpcReceipt struct {
+	TransactionHash string   `json:"transactionHash"`
+	Status          string   `json:"status"`
+	Logs            []rpcLog `json:"logs"`
+}
+
+func (l *Ethereum) rpc(method string, params []interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      atomic.AddInt64(&l.nextID, 1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := l.client.Post(l.rpcURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed with status %d: %s", method, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &reply); err != nil {
+		return fmt.Errorf("%s returned invalid JSON: %w", method, err)
+	}
+	if reply.Error != nil {
+		if strings.Contains(reply.Error.Message, "does not exist") || strings.Contains(reply.Error.Message, "invalid token ID") {
+			return ErrNotFound
+		}
+		return reply.Error
+	}
+	return json.Unmarshal(reply.Result, result)
+}
+
+// call runs a read-only contract call and returns the decoded return data.
+func (l *Ethereum) call(data []byte) ([]byte, error) {
+	var out string
+	err := l.rpc("eth_call", []interface{}{
+		map[string]string{"from": l.server, "to": l.contract, "data": "0x" + hex.EncodeToString(data)},
+		"latest",
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimPrefix(out, "0x"))
+}
+
+// sendAndWait sends a transaction from the server account and waits for it
+// to be mined successfully.
+func (l *Ethereum) sendAndWait(data []byte) (*rpcReceipt, error) {
+	var txHash string
+	err := l.rpc("eth_sendTransaction", []interface{}{
+		map[string]string{"from": l.server, "to": l.contract, "data": "0x" + hex.EncodeToString(data)},
+	}, &txHash)
+	if err != nil {
+		return nil, err
+	}
+	return l.waitForReceipt(txHash)
+}
+
+func (l *Ethereum) waitForReceipt(txHash string) (*rpcReceipt, error) {
+	deadline := time.Now().Add(l.receiptTimeout)
+	for {
+		var receipt *rpcReceipt
+		if err := l.rpc("eth_getTransactionReceipt", []interface{}{txHash}, &receipt); err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			if receipt.Status != "0x1" {
+				return nil, fmt.Errorf("transaction %s reverted", txHash)
+			}
+			return receipt, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("transaction %s not mined after %s", txHash, l.receiptTimeout)
+		}
+		time.Sleep(l.pollInterval)
+	}
+}
+
+// ABI encoding for the static and string arguments the contracts take.
+
+// abiAddress is a normalized 0x address argument.
+type abiAddress string
+
+func keccak(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func selector(signature string) []byte {
+	return keccak([]byte(signature))[:4]
+}
+
+func normalizeAddress(address string) (string, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+	raw, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	if err != nil || len(raw) != 20 {
+		return "", fmt.Errorf("invalid Ethereum address %q", address)
+	}
+	return "0x" + hex.EncodeToString(raw), nil
+}
+
+func word(b []byte) []byte {
+	out := make([]byte, 32)
+	copy(out[32-len(b):], b)
+	return out
+}
+
+// encodeCall ABI-encodes a call to sel. Arguments may be abiAddress,
+// *big.Int or string.
+func encodeCall(sel []byte, args ...interface{}) []byte {
+	var head, tail []byte
+	headSize := 32 * len(args)
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case abiAddress:
+			raw, _ := hex.DecodeString(strings.TrimPrefix(string(v), "0x"))
+			head = append(head, word(raw)...)
+		case *big.Int:
+			head = append(head, word(v.Bytes())...)
+		case string:
+			head = append(head, word(big.NewInt(int64(headSize+len(tail))).Bytes())...)
+			tail = append(tail, word(big.NewInt(int64(len(v))).Bytes())...)
+			padded := make([]byte, (len(v)+31)/32*32)
+			copy(padded, v)
+			tail = append(tail, padded...)
+		default:
+			panic(fmt.Sprintf("unsupported ABI argument %T", arg))
+		}
+	}
+	return append(append(append([]byte{}, sel...), head...), tail...)
+}
+
+func decodeBool(out []byte, index int) (bool, error) {
+	if len(out) < 32*(index+1) {
+		return false, errors.New("short ABI return data")
+	}
+	return out[32*index+31] == 1, nil
+}
+
+func decodeString(out []byte, index int) (string, error) {
+	if len(out) < 32*(index+1) {
+		return "", errors.New("short ABI return data")
+	}
+	offset := new(big.Int).SetBytes(out[32*index : 32*(index+1)])
+	if !offset.IsInt64() || offset.Int64()+32 > int64(len(out)) {
+		return "", errors.New("invalid ABI string offset")
+	}
+	start := int(offset.Int64())
+	length := new(big.Int).SetBytes(out[start : start+32])
+	if !length.IsInt64() || int64(start+32)+length.Int64() > int64(len(out)) {
+		return "", errors.New("invalid ABI string length")
+	}
+	return string(out[start+32 : start+32+int(length.Int64())]), nil
+}
+
+func topicAddress(topic string) string {
+	topic = strings.TrimPrefix(strings.ToLower(topic), "0x")
+	if len(topic) != 64 {
+		return ""
+	}
+	return "0x" + topic[24:]
+}
+
+func topicUint(topic string) *big.Int {
+	n, _ := new(big.Int).SetString(strings.TrimPrefix(topic, "0x"), 16)
+	if n == nil {
+		return new(big.Int)
+	}
+	return n
+}
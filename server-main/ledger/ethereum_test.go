@@ -0,0 +1,372 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testContract = "0x00000000000000000000000000000000000000c0"
+	testServer   = "0x00000000000000000000000000000000000000aa"
+	testUser     = "0x00000000000000000000000000000000000000bb"
+)
+
+// stubNode is a JSON-RPC endpoint that answers the calls Ethereum makes.
+// Contract calls are answered from calls by selector; transactions are
+// recorded and mined on the second receipt poll.
+type stubNode struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	calls   map[string]func(data []byte) (interface{}, *rpcError)
+	sent    [][]byte
+	raw     []string
+	receipt *rpcReceipt
+	polls   int
+}
+
+func newStubNode(t *testing.T) (*stubNode, *Ethereum) {
+	t.Helper()
+	node := &stubNode{t: t, calls: map[string]func([]byte) (interface{}, *rpcError){}}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	l, err := NewEthereum(Auth, server.URL, testContract, testServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.receiptTimeout = time.Second
+	l.pollInterval = time.Millisecond
+	return node, l
+}
+
+func (n *stubNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     int64             `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var result interface{}
+	var rpcErr *rpcError
+	switch req.Method {
+	case "eth_call", "eth_sendTransaction":
+		var tx struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+			Data string `json:"data"`
+		}
+		if err := json.Unmarshal(req.Params[0], &tx); err != nil {
+			n.t.Errorf("%s params: %v", req.Method, err)
+		}
+		if tx.From != testServer || tx.To != testContract {
+			n.t.Errorf("%s from %s to %s", req.Method, tx.From, tx.To)
+		}
+		data, _ := hex.DecodeString(strings.TrimPrefix(tx.Data, "0x"))
+		if req.Method == "eth_sendTransaction" {
+			n.sent = append(n.sent, data)
+			n.polls = 0
+			result = "0x" + strings.Repeat("ab", 32)
+			break
+		}
+		handler, ok := n.calls[hex.EncodeToString(data[:4])]
+		if !ok {
+			n.t.Errorf("unexpected eth_call to selector %x", data[:4])
+			rpcErr = &rpcError{Code: -32000, Message: "execution reverted"}
+			break
+		}
+		out, err := handler(data[4:])
+		result, rpcErr = out, err
+	case "eth_sendRawTransaction":
+		var raw string
+		if err := json.Unmarshal(req.Params[0], &raw); err != nil {
+			n.t.Errorf("%s params: %v", req.Method, err)
+		}
+		n.raw = append(n.raw, raw)
+		n.polls = 0
+		result = "0x" + strings.Repeat("cd", 32)
+	case "eth_getTransactionReceipt":
+		n.polls++
+		if n.polls > 1 {
+			result = n.receipt
+		}
+	default:
+		n.t.Errorf("unexpected method %s", req.Method)
+	}
+
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	if rpcErr != nil {
+		reply["error"] = rpcErr
+	} else {
+		reply["result"] = result
+	}
+	json.NewEncoder(w).Encode(reply)
+}
+
+func (n *stubNode) handle(sel []byte, handler func(data []byte) (interface{}, *rpcError)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.calls[hex.EncodeToString(sel)] = handler
+}
+
+// mine sets the receipt returned for the next transaction.
+func (n *stubNode) mine(status string, logs ...rpcLog) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.receipt = &rpcReceipt{TransactionHash: "0x" + strings.Repeat("ab", 32), Status: status, Logs: logs}
+}
+
+func (n *stubNode) lastSent() []byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.sent) == 0 {
+		n.t.Fatal("no transaction sent")
+	}
+	return n.sent[len(n.sent)-1]
+}
+
+func topicFor(b []byte) string {
+	return "0x" + hex.EncodeToString(word(b))
+}
+
+func transferLog(from, to string, tokenId int64) rpcLog {
+	fromRaw, _ := hex.DecodeString(strings.TrimPrefix(from, "0x"))
+	toRaw, _ := hex.DecodeString(strings.TrimPrefix(to, "0x"))
+	return rpcLog{
+		Address: testContract,
+		Topics:  []string{topicTransfer, topicFor(fromRaw), topicFor(toRaw), topicFor(big.NewInt(tokenId).Bytes())},
+	}
+}
+
+func TestEthereumMintAuthUsesRegistration(t *testing.T) {
+	node, l := newStubNode(t)
+	node.handle(selRegisteredUsers, func(data []byte) (interface{}, *rpcError) {
+		if !bytes.Equal(data, word(mustHex(t, testUser))) {
+			t.Errorf("registeredUsers(%x)", data)
+		}
+		// (bool exists, string username, string userType)
+		return "0x" + hex.EncodeToString(encodeCall(nil, big.NewInt(1), "alice", "admin")), nil
+	})
+	// A Transfer to someone else in the same receipt must be skipped.
+	node.mine("0x1",
+		transferLog("0x0000000000000000000000000000000000000000", testServer, 6),
+		transferLog("0x0000000000000000000000000000000000000000", testUser, 7))
+
+	id, err := l.Mint(strings.ToUpper(testUser[2:]), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "7" {
+		t.Errorf("minted ID %q, want 7", id)
+	}
+	want := encodeCall(selMintAuthToken, abiAddress(testUser), "alice", "admin", "login")
+	if got := node.lastSent(); !bytes.Equal(got, want) {
+		t.Errorf("mint data\n got %x\nwant %x", got, want)
+	}
+}
+
+func TestEthereumMintUnregisteredUser(t *testing.T) {
+	node, l := newStubNode(t)
+	node.handle(selRegisteredUsers, func([]byte) (interface{}, *rpcError) {
+		return "0x" + hex.EncodeToString(encodeCall(nil, big.NewInt(0), "", "")), nil
+	})
+	if _, err := l.Mint(testUser, nil); err == nil {
+		t.Fatal("minted for an unregistered user")
+	}
+	if len(node.sent) != 0 {
+		t.Error("sent a mint for an unregistered user")
+	}
+}
+
+func TestEthereumMintReq(t *testing.T) {
+	node, l := newStubNode(t)
+	l.kind = Req
+	node.mine("0x1", transferLog("0x0000000000000000000000000000000000000000", testUser, 42))
+
+	id, err := l.Mint(testUser, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "42" {
+		t.Errorf("minted ID %q, want 42", id)
+	}
+	if got, want := node.lastSent(), encodeCall(selMintReqToken, abiAddress(testUser)); !bytes.Equal(got, want) {
+		t.Errorf("mint data\n got %x\nwant %x", got, want)
+	}
+}
+
+func TestEthereumMintWithoutTransfer(t *testing.T) {
+	node, l := newStubNode(t)
+	l.kind = Req
+	node.mine("0x1")
+	if _, err := l.Mint(testUser, nil); err == nil {
+		t.Fatal("mint without a Transfer event succeeded")
+	}
+}
+
+func TestEthereumRevertedTransaction(t *testing.T) {
+	node, l := newStubNode(t)
+	node.mine("0x0")
+	if err := l.Burn(testUser, "5", ""); err == nil || !strings.Contains(err.Error(), "reverted") {
+		t.Fatalf("burn error %v, want a revert", err)
+	}
+}
+
+func TestEthereumReceiptTimeout(t *testing.T) {
+	node, l := newStubNode(t)
+	l.receiptTimeout = 10 * time.Millisecond
+	// Never mined: the receipt stays null.
+	node.receipt = nil
+	if err := l.Burn(testUser, "5", ""); err == nil || !strings.Contains(err.Error(), "not mined") {
+		t.Fatalf("burn error %v, want a timeout", err)
+	}
+}
+
+func TestEthereumBurn(t *testing.T) {
+	node, l := newStubNode(t)
+	node.mine("0x1")
+	if err := l.Burn(testUser, "5", "ignored"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := node.lastSent(), encodeCall(selBurnToken, big.NewInt(5)); !bytes.Equal(got, want) {
+		t.Errorf("burn data\n got %x\nwant %x", got, want)
+	}
+	if err := l.Burn(testUser, "not-a-number", ""); err == nil {
+		t.Error("burned an invalid token ID")
+	}
+}
+
+// TestEthereumTransferThenBurn runs the retire sequence the login and logout
+// flows use: the owner's signed transferToServer, then the server's burn.
+func TestEthereumTransferThenBurn(t *testing.T) {
+	node, l := newStubNode(t)
+	holder := testUser
+	node.handle(selOwnerOf, func(data []byte) (interface{}, *rpcError) {
+		if new(big.Int).SetBytes(data[:32]).Int64() != 9 {
+			return nil, &rpcError{Code: 3, Message: "execution reverted: ERC721: invalid token ID"}
+		}
+		raw, _ := hex.DecodeString(strings.TrimPrefix(holder, "0x"))
+		return "0x" + hex.EncodeToString(word(raw)), nil
+	})
+
+	owner, err := l.Owner("9")
+	if err != nil || owner != testUser {
+		t.Fatalf("Owner = %q, %v; want %s", owner, err, testUser)
+	}
+	if _, err := l.Owner("10"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Owner of a missing token: %v, want ErrNotFound", err)
+	}
+
+	call, err := l.TransferCall("9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0x" + hex.EncodeToString(encodeCall(selTransferServer, big.NewInt(9))); call != want {
+		t.Errorf("TransferCall = %s, want %s", call, want)
+	}
+
+	// A receipt that moves some other token does not count.
+	node.mine("0x1", transferLog(testUser, testServer, 8))
+	if err := l.TransferToServer(testUser, "9", "0xsigned"); err == nil {
+		t.Fatal("accepted a transfer of the wrong token")
+	}
+
+	node.mine("0x1", transferLog(testUser, testServer, 9))
+	if err := l.TransferToServer(testUser, "9", "0xsigned"); err != nil {
+		t.Fatal(err)
+	}
+	if len(node.raw) != 2 || node.raw[1] != "0xsigned" {
+		t.Errorf("raw transactions sent: %v", node.raw)
+	}
+	holder = testServer
+	if owner, _ := l.Owner("9"); owner != testServer {
+		t.Errorf("owner after transfer %q, want the server", owner)
+	}
+
+	node.mine("0x1", transferLog(testServer, "0x0000000000000000000000000000000000000000", 9))
+	if err := l.Burn(testUser, "9", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := node.lastSent(), encodeCall(selBurnToken, big.NewInt(9)); !bytes.Equal(got, want) {
+		t.Errorf("burn data\n got %x\nwant %x", got, want)
+	}
+}
+
+func TestEthereumVerify(t *testing.T) {
+	node, l := newStubNode(t)
+	node.handle(selVerifyOwner, func(data []byte) (interface{}, *rpcError) {
+		tokenId := new(big.Int).SetBytes(data[:32])
+		switch tokenId.Int64() {
+		case 1:
+			return "0x" + hex.EncodeToString(word([]byte{1})), nil
+		case 2:
+			return "0x" + hex.EncodeToString(word(nil)), nil
+		default:
+			return nil, &rpcError{Code: 3, Message: "execution reverted: Token does not exist"}
+		}
+	})
+
+	if ok, err := l.Verify("1", testUser); err != nil || !ok {
+		t.Errorf("Verify(1) = %v, %v; want true", ok, err)
+	}
+	if ok, err := l.Verify("2", testUser); err != nil || ok {
+		t.Errorf("Verify(2) = %v, %v; want false", ok, err)
+	}
+	if _, err := l.Verify("3", testUser); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Verify(3) error %v, want ErrNotFound", err)
+	}
+	if _, err := l.Verify("1", "not-an-address"); err == nil {
+		t.Error("verified an invalid address")
+	}
+}
+
+func TestEthereumRPCStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "node down", http.StatusBadGateway)
+	}))
+	defer server.Close()
+	l, err := NewEthereum(Req, server.URL, testContract, testServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Verify("1", testUser); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("Verify error %v, want the node's status", err)
+	}
+}
+
+func TestNewEthereumValidatesAddresses(t *testing.T) {
+	if _, err := NewEthereum(Auth, "", testContract, testServer); err == nil {
+		t.Error("accepted an empty RPC URL")
+	}
+	if _, err := NewEthereum(Auth, "http://node", "0x1234", testServer); err == nil {
+		t.Error("accepted a short contract address")
+	}
+	if _, err := NewEthereum(Auth, "http://node", testContract, "server"); err == nil {
+		t.Error("accepted an invalid server account")
+	}
+}
+
+func mustHex(t *testing.T, address string) []byte {
+	t.Helper()
+	raw, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
@@ -0,0 +1,259 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// InHouse talks to auth-blockchain or req-blockchain over their HTTP API.
+type InHouse struct {
+	baseURL string // e.g. http://localhost:18080
+	nftPath string // "/authnft" or "/reqnft"
+	client  *http.Client
+
+	// MinConfirmations makes Verify and Prove fail unless the NFT's
+	// ownership is sealed at least this many blocks deep. 0 accepts pending
+	// ownership.
+	MinConfirmations int
+}
+
+// NewInHouse returns a client for the in-house ledger at baseURL.
+func NewInHouse(kind Kind, baseURL string) *InHouse {
+	return &InHouse{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		nftPath: "/" + string(kind) + "nft",
+		client:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// post sends payload to the NFT endpoint and decodes a JSON reply into out
+// when out is non-nil. It returns ErrNotFound for 404 replies.
+func (l *InHouse) post(endpoint string, payload interface{}, out interface{}, okStatus ...int) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := l.client.Post(l.baseURL+l.nftPath+endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	return decodeReply(endpoint, resp, out, okStatus...)
+}
+
+// get fetches the NFT endpoint with query and decodes its JSON reply into
+// out.
+func (l *InHouse) get(endpoint string, query url.Values, out interface{}) error {
+	resp, err := l.client.Get(l.baseURL + l.nftPath + endpoint + "?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	return decodeReply(endpoint, resp, out)
+}
+
+// decodeReply checks resp's status and decodes its JSON body into out when
+// out is non-nil. It returns ErrNotFound for 404 replies.
+func decodeReply(endpoint string, resp *http.Response, out interface{}, okStatus ...int) error {
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	ok := resp.StatusCode == http.StatusOK
+	for _, status := range okStatus {
+		ok = ok || resp.StatusCode == status
+	}
+	if !ok {
+		return fmt.Errorf("%s failed with status %d: %s", endpoint, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("%s returned invalid JSON: %w", endpoint, err)
+	}
+	return nil
+}
+
+func (l *InHouse) Mint(owner string, details *TokenDetails) (string, error) {
+	payload := map[string]interface{}{"owner": owner}
+	if details != nil {
+		payload["username"] = details.Username
+		payload["user_type"] = details.UserType
+		payload["token_type"] = details.TokenType
+	}
+	return l.create(payload)
+}
+
+// MintLinked mints a token whose mint transaction carries proof.
+func (l *InHouse) MintLinked(owner string, proof BurnProof) (string, error) {
+	return l.create(map[string]interface{}{"owner": owner, "auth_burn": json.RawMessage(proof)})
+}
+
+// create posts payload to the create endpoint and returns the new NFT ID.
+func (l *InHouse) create(payload map[string]interface{}) (string, error) {
+	var response struct {
+		NFTId string `json:"nft_id"`
+	}
+	if err := l.post("/create", payload, &response, http.StatusCreated); err != nil {
+		return "", err
+	}
+	if response.NFTId == "" {
+		return "", fmt.Errorf("/create returned no nft_id")
+	}
+	return response.NFTId, nil
+}
+
+func (l *InHouse) TransferToServer(owner, nftId, signature string) error {
+	return l.post("/transfer", map[string]string{
+		"sender":          owner,
+		"nft_id":          nftId,
+		"signed_nfttoken": signature,
+	}, nil)
+}
+
+func (l *InHouse) Burn(owner, nftId, signature string) error {
+	return l.post("/burn", map[string]string{
+		"sender":    owner,
+		"nft_id":    nftId,
+		"signature": signature,
+	}, nil)
+}
+
+// BurnWithProof burns the token and fetches the ledger's signed proof of the
+// burn transaction.
+func (l *InHouse) BurnWithProof(owner, nftId, signature string) (BurnProof, error) {
+	var burned struct {
+		Receipt struct {
+			TxHash string `json:"tx_hash"`
+		} `json:"receipt"`
+	}
+	err := l.post("/burn", map[string]string{
+		"sender":    owner,
+		"nft_id":    nftId,
+		"signature": signature,
+	}, &burned)
+	if err != nil {
+		return nil, err
+	}
+	if burned.Receipt.TxHash == "" {
+		return nil, fmt.Errorf("/burn returned no receipt")
+	}
+
+	var proof json.RawMessage
+	if err := l.post("/burnproof", map[string]string{"tx_hash": burned.Receipt.TxHash}, &proof); err != nil {
+		return nil, err
+	}
+	return BurnProof(proof), nil
+}
+
+func (l *InHouse) Owner(nftId string) (string, error) {
+	var response struct {
+		Owner string `json:"owner"`
+	}
+	if err := l.post("/owner", map[string]string{"nft_id": nftId}, &response); err != nil {
+		return "", err
+	}
+	return response.Owner, nil
+}
+
+func (l *InHouse) Verify(nftId, address string) (bool, error) {
+	var response struct {
+		Valid bool   `json:"valid"`
+		Error string `json:"error"`
+	}
+	payload := map[string]interface{}{"nft_id": nftId, "address": address}
+	if l.MinConfirmations > 0 {
+		payload["min_confirmations"] = l.MinConfirmations
+	}
+	if err := l.post("/validate", payload, &response); err != nil {
+		return false, err
+	}
+	if !response.Valid && response.Error == "NFT not found" {
+		return false, ErrNotFound
+	}
+	if !response.Valid {
+		return false, nil
+	}
+	if err := l.verifyState(nftId, address); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Prove runs the ledger's challenge-response possession check and confirms
+// the answer against a state proof.
+func (l *InHouse) Prove(nftId, address string, sign func(message string) string) error {
+	var challenge struct {
+		Nonce   string `json:"nonce"`
+		Message string `json:"message"`
+	}
+	if err := l.post("/challenge", map[string]string{"nft_id": nftId, "address": address}, &challenge); err != nil {
+		return err
+	}
+	if challenge.Nonce == "" {
+		return fmt.Errorf("/challenge returned no nonce")
+	}
+
+	signature := sign(challenge.Message)
+	if signature == "" {
+		return fmt.Errorf("wallet did not sign the challenge")
+	}
+
+	var result struct {
+		Valid bool   `json:"valid"`
+		Error string `json:"error"`
+	}
+	payload := map[string]interface{}{
+		"nft_id":    nftId,
+		"address":   address,
+		"nonce":     challenge.Nonce,
+		"signature": signature,
+	}
+	if l.MinConfirmations > 0 {
+		payload["min_confirmations"] = l.MinConfirmations
+	}
+	if err := l.post("/prove", payload, &result); err != nil {
+		return err
+	}
+	if !result.Valid {
+		return fmt.Errorf("possession proof rejected: %s", result.Error)
+	}
+	return l.verifyState(nftId, address)
+}
+
+func (l *InHouse) Approve(owner, operator, nftId string, expiresAt int64, maxUses int, signature string) error {
+	return l.post("/approve", map[string]interface{}{
+		"owner":      owner,
+		"operator":   operator,
+		"nft_id":     nftId,
+		"expires_at": expiresAt,
+		"max_uses":   maxUses,
+		"signature":  signature,
+	}, nil, http.StatusCreated)
+}
+
+func (l *InHouse) Approvals(owner string) ([]Approval, error) {
+	var approvals []Approval
+	if err := l.get("/approvals", url.Values{"owner": {owner}}, &approvals); err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+func (l *InHouse) OperatorBurn(approvalID, nftId, signature string) error {
+	return l.post("/operator", map[string]string{
+		"approval_id": approvalID,
+		"action":      "burn",
+		"nft_id":      nftId,
+		"signature":   signature,
+	}, nil)
+}
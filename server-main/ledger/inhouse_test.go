@@ -0,0 +1,59 @@
+package ledger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInHouseProveSendsMinConfirmations(t *testing.T) {
+	var proved map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reqnft/challenge", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"nonce": "n1", "message": "challenge:n1"})
+	})
+	mux.HandleFunc("/reqnft/prove", func(w http.ResponseWriter, r *http.Request) {
+		proved = nil
+		json.NewDecoder(r.Body).Decode(&proved)
+		if proved["min_confirmations"] != float64(3) {
+			json.NewEncoder(w).Encode(map[string]interface{}{"valid": false, "error": "NFT has 0 confirmations, 3 required"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"valid": true})
+	})
+	proof := testStateProof(t, StateLeaf{Kind: "nft", ID: "nft-1", Owner: "addr"})
+	mux.HandleFunc("/state/proof", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(proof)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	l := NewInHouse(Req, server.URL)
+	var signed string
+	sign := func(message string) string {
+		signed = message
+		return "sig"
+	}
+	if err := l.Prove("nft-1", "addr", sign); err == nil {
+		t.Error("proof accepted without min_confirmations")
+	}
+	if _, ok := proved["min_confirmations"]; ok {
+		t.Error("sent min_confirmations when none is configured")
+	}
+
+	l.MinConfirmations = 3
+	if err := l.Prove("nft-1", "addr", sign); err != nil {
+		t.Fatal(err)
+	}
+	if signed != "challenge:n1" {
+		t.Errorf("signed %q, want the challenge message", signed)
+	}
+	if proved["nonce"] != "n1" || proved["signature"] != "sig" || proved["nft_id"] != "nft-1" {
+		t.Errorf("prove payload %v", proved)
+	}
+
+	if err := l.Prove("nft-1", "addr", func(string) string { return "" }); err == nil {
+		t.Error("proof accepted without a signature")
+	}
+}
@@ -0,0 +1,159 @@
+// Package ledger abstracts the token ledgers the login flow runs against.
+// A ledger is either the in-house chain (auth-blockchain, req-blockchain) or
+// an Ethereum node running AuthToken.sol or RequestToken.sol.
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrNotFound is returned when an NFT does not exist on the ledger.
+var ErrNotFound = errors.New("NFT not found")
+
+// Kind says which token a ledger holds.
+type Kind string
+
+const (
+	Auth Kind = "auth" // AuthNFTs: auth-blockchain or AuthToken.sol
+	Req  Kind = "req"  // ReqNFTs: req-blockchain or RequestToken.sol
+)
+
+// TokenDetails are the user details an AuthNFT is minted with. They mirror
+// AuthToken.sol's mintToken arguments and are ignored by req ledgers.
+type TokenDetails struct {
+	Username  string
+	UserType  string
+	TokenType string
+}
+
+// Ledger is the set of token operations the servers need. NFT IDs and
+// addresses are in the backend's own format: "nft-…" IDs and hex PKIX keys
+// on the in-house chain, decimal token IDs and 0x addresses on Ethereum.
+type Ledger interface {
+	// Mint creates a token owned by owner and returns its ID. details may
+	// be nil; auth ledgers then bind the details owner was registered with,
+	// and refuse owners that are not registered.
+	Mint(owner string, details *TokenDetails) (string, error)
+	// TransferToServer moves the owner's token to the server account.
+	// signature is the owner's authorization: a hex signature over the host
+	// wallet and NFT ID in-house, or a signed raw transaction on Ethereum.
+	TransferToServer(owner, nftId, signature string) error
+	// Burn retires a token. signature is the owner's hex signature over the
+	// NFT ID and "burn" in-house; on Ethereum the server burns a token it
+	// already holds and signature is unused.
+	Burn(owner, nftId, signature string) error
+	// Owner returns the current owner of a token.
+	Owner(nftId string) (string, error)
+	// Verify reports whether address owns the token.
+	Verify(nftId, address string) (bool, error)
+}
+
+// ServerBurner is implemented by ledgers that only burn tokens the server
+// account holds, so the owner must TransferToServer first. The Ethereum
+// contracts work this way. An in-house burn is signed by the owner and moves
+// the token to the host wallet itself.
+type ServerBurner interface {
+	// TransferCall returns the hex transaction data the owner's wallet
+	// signs into the raw transaction TransferToServer submits.
+	TransferCall(nftId string) (string, error)
+}
+
+// Prover is implemented by ledgers that check possession with a challenge
+// the owner's wallet signs, rather than by address alone. Only the in-house
+// ledger does.
+type Prover interface {
+	// Prove fetches a challenge for nftId and address, has sign sign its
+	// message and submits the signature. It returns nil if the ledger
+	// accepted the proof. sign returns "" when the wallet refuses.
+	Prove(nftId, address string, sign func(message string) string) error
+}
+
+// Approval is an operator approval as listed by a ledger.
+type Approval struct {
+	ID        string `json:"approval_id"`
+	Operator  string `json:"operator"`
+	NFTId     string `json:"nft_id"`
+	ExpiresAt int64  `json:"expires_at"`
+	MaxUses   int    `json:"max_uses"`
+	Uses      int    `json:"uses"`
+	Revoked   bool   `json:"revoked"`
+}
+
+// Operator is implemented by ledgers where an owner can approve another key
+// to act on a token. Only the in-house ledger does.
+type Operator interface {
+	// Approve submits the owner's signed approval of operator for nftId.
+	Approve(owner, operator, nftId string, expiresAt int64, maxUses int, signature string) error
+	// Approvals lists the approvals owner has granted.
+	Approvals(owner string) ([]Approval, error)
+	// OperatorBurn burns nftId under an approval. signature is the
+	// operator's signature over the ledger's operator message.
+	OperatorBurn(approvalID, nftId, signature string) error
+}
+
+// BurnProof is an auth ledger's signed proof that an AuthNFT was burned. The
+// server passes it from the auth ledger to the req ledger unchanged.
+type BurnProof json.RawMessage
+
+// BurnProver is implemented by auth ledgers that can prove a burn. Only the
+// in-house ledger does.
+type BurnProver interface {
+	// BurnWithProof burns like Burn and returns the ledger's proof of it.
+	BurnWithProof(owner, nftId, signature string) (BurnProof, error)
+}
+
+// LinkedMinter is implemented by req ledgers that record the AuthNFT burn
+// behind each ReqNFT. Only the in-house ledger does.
+type LinkedMinter interface {
+	// MintLinked mints like Mint, carrying the proof of the burn that
+	// authorized the token.
+	MintLinked(owner string, proof BurnProof) (string, error)
+}
+
+// FromEnv builds the ledger of the given kind from the environment. The
+// prefix is AUTH or REQ:
+//
+//	<PREFIX>_LEDGER_BACKEND     "inhouse" (default) or "ethereum"
+//	<PREFIX>_BLOCKCHAIN_URL     in-house ledger URL
+//	<PREFIX>_MIN_CONFIRMATIONS  in-house blocks required before Verify or Prove passes
+//	<PREFIX>_ETH_RPC_URL        Ethereum JSON-RPC endpoint
+//	<PREFIX>_ETH_CONTRACT       deployed token contract address
+//	<PREFIX>_ETH_SERVER_ACCOUNT unlocked server account on the node
+func FromEnv(kind Kind, defaultURL string) (Ledger, error) {
+	prefix := "AUTH"
+	if kind == Req {
+		prefix = "REQ"
+	}
+
+	switch backend := os.Getenv(prefix + "_LEDGER_BACKEND"); backend {
+	case "", "inhouse":
+		url := os.Getenv(prefix + "_BLOCKCHAIN_URL")
+		if url == "" {
+			url = defaultURL
+		}
+		l := NewInHouse(kind, url)
+		if s := os.Getenv(prefix + "_MIN_CONFIRMATIONS"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s_MIN_CONFIRMATIONS %q", prefix, s)
+			}
+			l.MinConfirmations = n
+		}
+		return l, nil
+	case "ethereum":
+		return NewEthereum(kind,
+			os.Getenv(prefix+"_ETH_RPC_URL"),
+			os.Getenv(prefix+"_ETH_CONTRACT"),
+			os.Getenv(prefix+"_ETH_SERVER_ACCOUNT"))
+	default:
+		return nil, fmt.Errorf("unknown %s_LEDGER_BACKEND %q", prefix, backend)
+	}
+}
+
+// requestTimeout bounds every call to a ledger backend.
+const requestTimeout = 15 * time.Second
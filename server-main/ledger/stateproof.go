@@ -0,0 +1,220 @@
+package ledger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrNoStateProof is returned when the ledger has no state proof to give,
+// such as for a block sealed before the ledger kept state roots.
+var ErrNoStateProof = errors.New("no state proof available")
+
+// StateProof is an in-house ledger's proof that an NFT has a value, or is
+// absent, in the state root committed to by a block header. The types
+// and hashing below must match stateroot.go on the ledgers.
+type StateProof struct {
+	Block     int         `json:"block"`
+	BlockHash string      `json:"block_hash"`
+	Header    BlockHeader `json:"header"`
+	Key       string      `json:"key"`
+	Leaf      *StateLeaf  `json:"leaf,omitempty"`
+	Other     *ProofLeaf  `json:"other,omitempty"`
+	Siblings  []string    `json:"siblings"`
+}
+
+// BlockHeader is the hashed part of an in-house block. Field order matters:
+// the block hash is the SHA-256 of its JSON encoding.
+type BlockHeader struct {
+	Index        int
+	Timestamp    string
+	Proof        int
+	PreviousHash string
+	TxRoot       string
+	TxCount      int
+	StateRoot    string `json:",omitempty"`
+}
+
+// StateLeaf is the state value of one NFT or wallet.
+type StateLeaf struct {
+	Kind   string `json:"kind"` // "nft" or "wallet"
+	ID     string `json:"id"`
+	Owner  string `json:"owner,omitempty"`
+	Burned bool   `json:"burned,omitempty"`
+}
+
+// ProofLeaf is the other leaf found on the path in a non-existence proof.
+type ProofLeaf struct {
+	Key       string `json:"key"`
+	ValueHash string `json:"value_hash"`
+}
+
+// VerifyNFT checks the proof for nftId. On success, Leaf is the NFT's state
+// as of the block, or nil if it did not exist.
+func (p *StateProof) VerifyNFT(nftId string) error {
+	if err := p.verify(sha256.Sum256([]byte("nft:" + nftId))); err != nil {
+		return err
+	}
+	if p.Leaf != nil && (p.Leaf.Kind != "nft" || p.Leaf.ID != nftId) {
+		return errors.New("proof leaf is not for this NFT")
+	}
+	return nil
+}
+
+// OwnedBy reports whether a verified NFT proof shows address holding the NFT
+// unburned.
+func (p *StateProof) OwnedBy(address string) bool {
+	return p.Leaf != nil && !p.Leaf.Burned && p.Leaf.Owner == address
+}
+
+// verify checks that the header hashes to BlockHash and that the path for
+// key reaches the header's state root.
+func (p *StateProof) verify(key [32]byte) error {
+	headerBytes, err := json.Marshal(p.Header)
+	if err != nil {
+		return err
+	}
+	headerHash := sha256.Sum256(headerBytes)
+	if hex.EncodeToString(headerHash[:]) != p.BlockHash {
+		return errors.New("header does not match the block hash")
+	}
+	if p.Header.Index != p.Block {
+		return errors.New("header is for a different block")
+	}
+	if p.Key != hex.EncodeToString(key[:]) {
+		return errors.New("proof is for a different key")
+	}
+	if p.Leaf != nil && p.Other != nil {
+		return errors.New("proof has both a leaf and a non-existence leaf")
+	}
+
+	var hash [32]byte
+	switch {
+	case p.Leaf != nil:
+		value, err := json.Marshal(p.Leaf)
+		if err != nil {
+			return err
+		}
+		valueHash := sha256.Sum256(value)
+		hash = sha256.Sum256(concat([]byte{0}, key[:], valueHash[:]))
+	case p.Other != nil:
+		otherKey, err := hex.DecodeString(p.Other.Key)
+		if err != nil || len(otherKey) != 32 || bytes.Equal(otherKey, key[:]) {
+			return errors.New("invalid non-existence leaf key")
+		}
+		valueHash, err := hex.DecodeString(p.Other.ValueHash)
+		if err != nil || len(valueHash) != 32 {
+			return errors.New("invalid non-existence leaf value")
+		}
+		for depth := range p.Siblings {
+			if keyBit(otherKey, depth) != keyBit(key[:], depth) {
+				return errors.New("non-existence leaf is not on the key's path")
+			}
+		}
+		hash = sha256.Sum256(concat([]byte{0}, otherKey, valueHash))
+	}
+
+	if len(p.Siblings) > 256 {
+		return errors.New("proof is deeper than the key")
+	}
+	for depth := len(p.Siblings) - 1; depth >= 0; depth-- {
+		sibling, err := hex.DecodeString(p.Siblings[depth])
+		if err != nil || len(sibling) != 32 {
+			return errors.New("invalid sibling hash")
+		}
+		if keyBit(key[:], depth) == 0 {
+			hash = sha256.Sum256(concat([]byte{1}, hash[:], sibling))
+		} else {
+			hash = sha256.Sum256(concat([]byte{1}, sibling, hash[:]))
+		}
+	}
+	if hex.EncodeToString(hash[:]) != p.Header.StateRoot {
+		return errors.New("proof does not reach the state root")
+	}
+	return nil
+}
+
+func keyBit(key []byte, depth int) byte {
+	return (key[depth/8] >> (7 - uint(depth%8))) & 1
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, part := range parts {
+		b = append(b, part...)
+	}
+	return b
+}
+
+// NFTStateProof fetches the state proof for nftId against block, or the
+// latest block when block is 0, and verifies it locally. Callers that do not
+// trust this node should also check BlockHash against a hash obtained
+// elsewhere.
+func (l *InHouse) NFTStateProof(nftId string, block int) (*StateProof, error) {
+	query := url.Values{"nft_id": {nftId}}
+	if block > 0 {
+		query.Set("block", strconv.Itoa(block))
+	}
+	resp, err := l.client.Get(l.baseURL + "/state/proof?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNoStateProof, strings.TrimSpace(string(body)))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("/state/proof failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var proof StateProof
+	if err := json.Unmarshal(body, &proof); err != nil {
+		return nil, fmt.Errorf("/state/proof returned invalid JSON: %w", err)
+	}
+	if block > 0 && proof.Block != block {
+		return nil, fmt.Errorf("/state/proof answered for block %d, not %d", proof.Block, block)
+	}
+	if err := proof.VerifyNFT(nftId); err != nil {
+		return nil, fmt.Errorf("invalid state proof for %s: %w", nftId, err)
+	}
+	return &proof, nil
+}
+
+// verifyState checks ownership of nftId by address against a verified state
+// proof of the latest block. The header and proof come from the same node as
+// the answer to /prove or /validate, so this only checks that the node's
+// answers are consistent with each other; it does not stop a dishonest node
+// hiding a sealed burn or transfer, which would take checking BlockHash
+// against an independent anchor. Ownership that is not sealed yet has no
+// proof, so one is only required when MinConfirmations asks for sealed
+// ownership.
+func (l *InHouse) verifyState(nftId, address string) error {
+	proof, err := l.NFTStateProof(nftId, 0)
+	if err != nil {
+		if errors.Is(err, ErrNoStateProof) && l.MinConfirmations == 0 {
+			return nil
+		}
+		return err
+	}
+	switch {
+	case proof.OwnedBy(address):
+		return nil
+	case proof.Leaf != nil && proof.Leaf.Burned:
+		return fmt.Errorf("state proof at block %d shows %s burned", proof.Block, nftId)
+	case l.MinConfirmations > 0:
+		return fmt.Errorf("state proof at block %d does not show %s owned by %s", proof.Block, nftId, address)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package ledger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testStateProof builds a proof for a state holding only leaf, whose root is
+// the leaf hash itself.
+func testStateProof(t *testing.T, leaf StateLeaf) StateProof {
+	t.Helper()
+	key := sha256.Sum256([]byte(leaf.Kind + ":" + leaf.ID))
+	value, err := json.Marshal(leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valueHash := sha256.Sum256(value)
+	root := sha256.Sum256(concat([]byte{0}, key[:], valueHash[:]))
+
+	header := BlockHeader{Index: 4, Timestamp: "2025-01-01 00:00:00", PreviousHash: "prev", StateRoot: hex.EncodeToString(root[:])}
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockHash := sha256.Sum256(headerBytes)
+	return StateProof{
+		Block:     4,
+		BlockHash: hex.EncodeToString(blockHash[:]),
+		Header:    header,
+		Key:       hex.EncodeToString(key[:]),
+		Leaf:      &leaf,
+	}
+}
+
+// stateLedger returns an in-house ledger whose /state/proof answers with
+// proof, or 404 when proof is nil.
+func stateLedger(t *testing.T, proof *StateProof) *InHouse {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/state/proof" {
+			http.NotFound(w, r)
+			return
+		}
+		if proof == nil {
+			http.Error(w, "block 1 has no state root", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(proof)
+	}))
+	t.Cleanup(server.Close)
+	return NewInHouse(Auth, server.URL)
+}
+
+func TestVerifyState(t *testing.T) {
+	owned := testStateProof(t, StateLeaf{Kind: "nft", ID: "nft-1", Owner: "alice"})
+	burned := testStateProof(t, StateLeaf{Kind: "nft", ID: "nft-1", Owner: "host", Burned: true})
+	moved := testStateProof(t, StateLeaf{Kind: "nft", ID: "nft-1", Owner: "bob"})
+	tampered := testStateProof(t, StateLeaf{Kind: "nft", ID: "nft-1", Owner: "bob"})
+	tampered.Leaf.Owner = "alice"
+
+	tests := []struct {
+		name             string
+		proof            *StateProof
+		minConfirmations int
+		ok               bool
+	}{
+		{"owned", &owned, 0, true},
+		{"owned and sealed", &owned, 2, true},
+		{"burned", &burned, 0, false},
+		{"other owner, pending allowed", &moved, 0, true},
+		{"other owner, sealed required", &moved, 2, false},
+		{"no proof, pending allowed", nil, 0, true},
+		{"no proof, sealed required", nil, 2, false},
+		{"tampered leaf", &tampered, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := stateLedger(t, tt.proof)
+			l.MinConfirmations = tt.minConfirmations
+			err := l.verifyState("nft-1", "alice")
+			if (err == nil) != tt.ok {
+				t.Errorf("verifyState error %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNFTStateProofNotFound(t *testing.T) {
+	l := stateLedger(t, nil)
+	if _, err := l.NFTStateProof("nft-1", 0); !errors.Is(err, ErrNoStateProof) {
+		t.Errorf("error %v, want ErrNoStateProof", err)
+	}
+}
@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware records request count, status and latency for every matched
+// gin route. Unmatched requests are grouped under the "unmatched" route.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpDuration.ObserveSince(start, route, c.Request.Method)
+		httpRequests.Inc(route, c.Request.Method, strconv.Itoa(c.Writer.Status()))
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"nexasecure/logger"
 	"nexasecure/handler"
+	"nexasecure/ledger"
+	"nexasecure/metrics"
+	"nexasecure/util"
 )
 
 func main() {
@@ -19,8 +22,33 @@ func main() {
 		os.Exit(1)
 	}
 	defer log.Sync()
+	handler.SetLogger(log)
+	util.SetLogger(log)
+
+	// Load or create the key this server signs delegated NFT operations with
+	operatorAddress, err := util.InitOperatorKey(os.Getenv("OPERATOR_KEY_FILE"))
+	if err != nil {
+		log.Error("Failed to initialize operator key", "error", err)
+		os.Exit(1)
+	}
+	log.Info("Operator key ready", "address", operatorAddress)
+
+	// Pick the AuthNFT and ReqNFT ledger backends
+	authLedger, err := ledger.FromEnv(ledger.Auth, "http://localhost:18080")
+	if err != nil {
+		log.Error("Failed to configure auth ledger", "error", err)
+		os.Exit(1)
+	}
+	reqLedger, err := ledger.FromEnv(ledger.Req, "http://localhost:18085")
+	if err != nil {
+		log.Error("Failed to configure req ledger", "error", err)
+		os.Exit(1)
+	}
+	util.SetLedgers(authLedger, reqLedger)
 
 	router := gin.Default()
+	router.Use(metrics.GinMiddleware())
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	router.POST("/login", handler.LoginHandler)
 	//router.POST("/verifyotp", handler.MFAHandler)
@@ -8,18 +8,12 @@ import (
 	"os"
 )
 
-var log logger.Logger
+// log is the service logger. main injects it with SetLogger at startup.
+var log = logger.NewNop()
 
-
-//init logger
-func init() {
-	config := logger.NewConfigFromEnv()
-	var logerr error
-	log, logerr = logger.NewLogger(config)
-	if logerr != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", logerr)
-		os.Exit(1)
-	}
+// SetLogger sets the logger used by the util package.
+func SetLogger(l logger.Logger) {
+	log = l
 }
 
 
@@ -109,64 +103,6 @@ func GetAuthNFT(authPubAddr string) string {
 	return authNFT
 }
 
-//verify Auth NFT
-func ValidateAuthNFT(authPubAddr string, authNFT string) bool {
-	SocketServerUrl := "http://localhost:18080"//os.Getenv("SOCKET_SERVER_URL")
-	ApiEndpoint := "/authnft/validate"
-	url := SocketServerUrl + ApiEndpoint
-	payload := map[string]string{
-		"address": authPubAddr, 
-		"nft_id": authNFT,
-	}
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		log.Error(fmt.Sprintf("Error marshalling JSON: %v", err))
-		return false
-	}
-	// fmt.Println(payload)
-	// fmt.Println(jsonData)
-	headers := map[string]string{
-		"Content-Type": "application/json",
-	}
-	//fmt.Println("send api call  to validate nft to url", url)
-	statusCode, responseBody, err := MakeAPICall("POST", url, headers, jsonData)
-	if err != nil {
-		log.Error(fmt.Sprintf("Error making API call: %v", err))
-		return false
-	}
-	// fmt.Println(responseBody)
-	if statusCode != http.StatusOK {
-		log.Error(fmt.Sprintf("API call failed with status: %d, response: %s", statusCode, responseBody))
-		return false
-	}	
-	var response map[string]interface{}
-	err = json.Unmarshal(responseBody, &response)
-	if err != nil {
-		log.Error(fmt.Sprintf("Error unmarshalling JSON: %v", err))
-		return false
-	}
-	fmt.Println("response", response)
-	if errorObj, exists := response["error"]; exists {
-		log.Error(fmt.Sprintf("API response contains error: %v", errorObj))
-		return false
-	}
-
-	valid, exists := response["valid"]
-	if !exists {
-		log.Error("valid not found in response")
-		return false
-	}
-
-	isValid, ok := valid.(bool)
-	if !ok {
-		log.Error("valid field is not of type bool")
-		return false
-	}
-
-	log.Info("AuthNFT successfully verified")
-	return isValid
-}
-
 //sign the auth NFT and return the signed token
 func SignAuthNFT(authPubAddr string, authNFT string) string {
 	SocketServerUrl := "http://localhost:10081"//os.Getenv("SOCKET_SERVER_URL")
@@ -3,61 +3,16 @@ package util
 import (
 	"encoding/json"
 	"net/http"
-	"os"
 	"fmt"
-	"nexasecure/logger"
-	
+	"nexasecure/ledger"
 )
 
-
+// MintNewAuthNFT mints an AuthNFT to userAddr bound to the details the user
+// was registered with on the auth ledger.
 func MintNewAuthNFT(userAddr string) string {
-	config := logger.NewConfigFromEnv()
-
-	// Initialize logger
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
-	baseURL := "http://localhost:18080"//os.Getenv("BASE_URL")
-	if baseURL == "" {
-		log.Fatal("BASE_URL not set in .env file")
-	}
-
-	apiEndpoint := "/authnft/create"
-	url := baseURL + apiEndpoint
-
-	payload := map[string]string{"owner": userAddr}
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		log.Error(fmt.Sprintf("Error marshalling JSON: %v", err))
-		return ""
-	}
-
-	headers := map[string]string{
-		"Content-Type": "application/json",
-	}
-	statusCode, responseBody, err := MakeAPICall("POST", url, headers, jsonData)
+	newNFT, err := authLedger.Mint(userAddr, nil)
 	if err != nil {
-		log.Error(fmt.Sprintf("Error making API call: %v", err))
-		return ""
-	}
-	if statusCode != http.StatusOK && statusCode != http.StatusCreated {
-		log.Error(fmt.Sprintf("API call failed with status: %d, response: %s", statusCode, responseBody))
-		return ""
-	}
-
-	var response map[string]string
-	err = json.Unmarshal(responseBody, &response)
-	if err != nil {
-		log.Error(fmt.Sprintf("Error unmarshalling JSON: %v", err))
-		return ""
-	}
-	// fmt.Println("response", response)
-	newNFT, exists := response["nft_id"]
-	if !exists {
-		log.Error("newnft not found in response")
+		log.Error(fmt.Sprintf("Error minting auth NFT: %v", err))
 		return ""
 	}
 	log.Info("auth NFT successfully minted")
@@ -113,3 +68,51 @@ func StoreAuthNFT(AuthPubAddr string, newNFT string) bool {
 	log.Info("Auth NFT successfully stored")
 	return true
 }
+
+// BurnAuthNFT burns authNFT with the user's auth wallet signing, and returns
+// the auth ledger's proof of the burn for the ReqNFT mint when the ledger can
+// prove burns. On ledgers that only burn server-held tokens the AuthNFT is
+// transferred to the server first.
+func BurnAuthNFT(authPubAddr string, authNFT string) (ledger.BurnProof, bool) {
+	sign := func(message string) string {
+		return signWithWallet("/signauthwallet", "authwallPubAddr", authPubAddr, message)
+	}
+	if !transferToServer(authLedger, authPubAddr, authNFT, sign) {
+		return nil, false
+	}
+
+	prover, canProve := authLedger.(ledger.BurnProver)
+	signature := ""
+	if _, serverBurns := authLedger.(ledger.ServerBurner); !serverBurns {
+		signature = sign(authNFT + "burn")
+		if signature == "" {
+			log.Error("Auth wallet did not sign the burn")
+			return nil, false
+		}
+	}
+
+	var proof ledger.BurnProof
+	var err error
+	if canProve {
+		proof, err = prover.BurnWithProof(authPubAddr, authNFT, signature)
+	} else {
+		err = authLedger.Burn(authPubAddr, authNFT, signature)
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("Error burning auth NFT: %v", err))
+		return nil, false
+	}
+	log.Info("Auth NFT burned")
+	return proof, true
+}
+
+// ReissueAuthNFT mints a new AuthNFT for authPubAddr and stores it in the
+// wallet. Login uses it when the AuthNFT was burned but the ReqNFT could not
+// be minted.
+func ReissueAuthNFT(authPubAddr string) bool {
+	newNFT := MintNewAuthNFT(authPubAddr)
+	if newNFT == "" {
+		return false
+	}
+	return StoreAuthNFT(authPubAddr, newNFT)
+}
@@ -0,0 +1,56 @@
+package util
+
+import (
+	"fmt"
+	"nexasecure/ledger"
+	"strings"
+)
+
+// Ledgers the login and logout flows mint on. They default to the in-house
+// chains and are replaced at startup from the environment.
+var (
+	authLedger ledger.Ledger = ledger.NewInHouse(ledger.Auth, "http://localhost:18080")
+	reqLedger  ledger.Ledger = ledger.NewInHouse(ledger.Req, "http://localhost:18085")
+)
+
+// SetLedgers selects the AuthNFT and ReqNFT ledger backends.
+func SetLedgers(auth, req ledger.Ledger) {
+	authLedger = auth
+	reqLedger = req
+}
+
+// transferToServer moves nftId from owner to the server account on ledgers
+// that only burn server-held tokens, having sign sign the owner's transfer.
+// A token the server already holds, from an earlier attempt, is left alone.
+// Other ledgers need no transfer before a burn.
+func transferToServer(l ledger.Ledger, owner string, nftId string, sign func(message string) string) bool {
+	burner, ok := l.(ledger.ServerBurner)
+	if !ok {
+		return true
+	}
+	holder, err := l.Owner(nftId)
+	if err != nil {
+		log.Error(fmt.Sprintf("Error looking up NFT owner: %v", err))
+		return false
+	}
+	if !strings.EqualFold(strings.TrimPrefix(holder, "0x"), strings.TrimPrefix(owner, "0x")) {
+		log.Info(fmt.Sprintf("NFT %s already held by %s, not transferring", nftId, holder))
+		return true
+	}
+	call, err := burner.TransferCall(nftId)
+	if err != nil {
+		log.Error(fmt.Sprintf("Error building transfer: %v", err))
+		return false
+	}
+	signedTx := sign(call)
+	if signedTx == "" {
+		log.Error("Wallet did not sign the transfer to the server")
+		return false
+	}
+	if err := l.TransferToServer(owner, nftId, signedTx); err != nil {
+		log.Error(fmt.Sprintf("Error transferring NFT to the server: %v", err))
+		return false
+	}
+	log.Info(fmt.Sprintf("NFT %s transferred to the server", nftId))
+	return true
+}
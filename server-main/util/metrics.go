@@ -0,0 +1,23 @@
+package util
+
+import (
+	"nexasecure/metrics"
+	"time"
+)
+
+// Login flow metrics exposed on /metrics.
+var (
+	loginStepDuration = metrics.NewHistogramVec("login_step_duration_seconds",
+		"Latency of each step of the NFT login flow, by step and outcome.", nil, "step", "outcome")
+	loginFlows = metrics.NewCounterVec("login_nft_flows_total",
+		"Completed NFT login flows, by outcome.", "outcome")
+)
+
+// observeStep records how long a login step took and whether it succeeded.
+func observeStep(step string, start time.Time, ok bool) {
+	outcome := "ok"
+	if !ok {
+		outcome = "failed"
+	}
+	loginStepDuration.ObserveSince(start, step, outcome)
+}
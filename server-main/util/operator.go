@@ -0,0 +1,187 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"nexasecure/ledger"
+	"os"
+	"time"
+)
+
+// Approvals granted to this server at login let logout retire the ReqNFT
+// without the user's wallet.
+const (
+	reqApprovalLifetime = 24 * time.Hour
+	reqApprovalMaxUses  = 1
+)
+
+// operatorKey signs operations this server performs on users' behalf under
+// their approvals. It is loaded once at startup by InitOperatorKey.
+var operatorKey *ecdsa.PrivateKey
+
+// operatorAddress is the ledger address of operatorKey: the hex encoded
+// PKIX public key, the same form the ledgers use for wallets.
+var operatorAddress string
+
+// InitOperatorKey loads the operator key from path, creating it if the file
+// does not exist. An empty path generates a key that lives only as long as
+// the process.
+func InitOperatorKey(path string) (string, error) {
+	var key *ecdsa.PrivateKey
+	data, err := os.ReadFile(path)
+	switch {
+	case path == "" || errors.Is(err, os.ErrNotExist):
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return "", err
+		}
+		if path != "" {
+			der, err := x509.MarshalECPrivateKey(key)
+			if err != nil {
+				return "", err
+			}
+			pemBytes := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+			if err := os.WriteFile(path, pemBytes, 0600); err != nil {
+				return "", fmt.Errorf("failed to write operator key: %w", err)
+			}
+		}
+	case err != nil:
+		return "", fmt.Errorf("failed to read operator key: %w", err)
+	default:
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != "EC PRIVATE KEY" {
+			return "", errors.New("operator key file is not a PEM EC private key")
+		}
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse operator key: %w", err)
+		}
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	operatorKey = key
+	operatorAddress = hex.EncodeToString(der)
+	return operatorAddress, nil
+}
+
+func operatorSign(message string) (string, error) {
+	if operatorKey == nil {
+		return "", errors.New("operator key not initialized")
+	}
+	hash := sha256.Sum256([]byte(message))
+	sig, err := ecdsa.SignASN1(rand.Reader, operatorKey, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sig), nil
+}
+
+// ApproveServerForReqNFT has the user's request wallet approve this server to
+// burn reqNFT, so logout can retire it even if the wallet is unavailable.
+func ApproveServerForReqNFT(reqPubAddr string, reqNFT string) bool {
+	if operatorKey == nil {
+		log.Error("Operator key not initialized")
+		return false
+	}
+	operator, ok := reqLedger.(ledger.Operator)
+	if !ok {
+		log.Warn("Req ledger does not support operator approvals")
+		return false
+	}
+
+	expiresAt := time.Now().Add(reqApprovalLifetime).Unix()
+	// Must match ApprovalMessage on the req ledger.
+	message := fmt.Sprintf("approve:req:%s:%s::%d:%d", operatorAddress, reqNFT, expiresAt, reqApprovalMaxUses)
+	signature := signWithWallet("/signreqwallet", "requestwallPubAddr", reqPubAddr, message)
+	if signature == "" {
+		log.Error("Wallet did not sign the approval")
+		return false
+	}
+
+	if err := operator.Approve(reqPubAddr, operatorAddress, reqNFT, expiresAt, reqApprovalMaxUses, signature); err != nil {
+		log.Error(fmt.Sprintf("Error approving server for ReqNFT: %v", err))
+		return false
+	}
+	log.Info("Server approved to retire ReqNFT")
+	return true
+}
+
+// BurnReqNFTsAsOperator burns every ReqNFT that reqPubAddr has approved this
+// server to burn, without involving the user's wallet. It returns the number
+// of NFTs burned.
+func BurnReqNFTsAsOperator(reqPubAddr string) int {
+	if operatorKey == nil {
+		log.Error("Operator key not initialized")
+		return 0
+	}
+	operator, ok := reqLedger.(ledger.Operator)
+	if !ok {
+		log.Warn("Req ledger does not support operator approvals")
+		return 0
+	}
+
+	approvals, err := operator.Approvals(reqPubAddr)
+	if err != nil {
+		log.Error(fmt.Sprintf("Error listing approvals: %v", err))
+		return 0
+	}
+
+	burned := 0
+	now := time.Now().Unix()
+	for _, a := range approvals {
+		if a.Operator != operatorAddress || a.NFTId == "" || a.Revoked || a.Uses >= a.MaxUses || now >= a.ExpiresAt {
+			continue
+		}
+		// Must match OperatorMessage on the req ledger.
+		signature, err := operatorSign(fmt.Sprintf("operate:%s:burn:%s:%d", a.ID, a.NFTId, a.Uses))
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to sign operator request: %v", err))
+			return burned
+		}
+		if err := operator.OperatorBurn(a.ID, a.NFTId, signature); err != nil {
+			// An NFT the user already retired is not an error here.
+			log.Warn(fmt.Sprintf("Operator burn of %s failed: %v", a.NFTId, err))
+			continue
+		}
+		burned++
+		log.Info(fmt.Sprintf("ReqNFT %s burned by server under approval", a.NFTId))
+	}
+	return burned
+}
+
+// BurnReqNFT burns reqNFT on the req ledger with the user's request wallet
+// signing. On ledgers that only burn server-held tokens the ReqNFT is
+// transferred to the server first.
+func BurnReqNFT(reqPubAddr string, reqNFT string) bool {
+	sign := func(message string) string {
+		return signWithWallet("/signreqwallet", "requestwallPubAddr", reqPubAddr, message)
+	}
+	if !transferToServer(reqLedger, reqPubAddr, reqNFT, sign) {
+		return false
+	}
+
+	signature := ""
+	if _, serverBurns := reqLedger.(ledger.ServerBurner); !serverBurns {
+		signature = sign(reqNFT + "burn")
+		if signature == "" {
+			log.Error("Request wallet did not sign the burn")
+			return false
+		}
+	}
+	if err := reqLedger.Burn(reqPubAddr, reqNFT, signature); err != nil {
+		log.Error(fmt.Sprintf("Error burning req NFT: %v", err))
+		return false
+	}
+	log.Info("Req NFT burned")
+	return true
+}
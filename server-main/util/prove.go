@@ -0,0 +1,80 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"nexasecure/ledger"
+	"strings"
+)
+
+// ProveAuthNFT asks the user's auth wallet to prove it holds authNFT on the
+// auth ledger.
+func ProveAuthNFT(authPubAddr string, authNFT string) bool {
+	return proveNFTOwnership(authLedger, authPubAddr, authNFT, func(message string) string {
+		return signWithWallet("/signauthwallet", "authwallPubAddr", authPubAddr, message)
+	})
+}
+
+// ProveReqNFT asks the user's request wallet to prove it holds reqNFT on the
+// req ledger.
+func ProveReqNFT(reqPubAddr string, reqNFT string) bool {
+	return proveNFTOwnership(reqLedger, reqPubAddr, reqNFT, func(message string) string {
+		return signWithWallet("/signreqwallet", "requestwallPubAddr", reqPubAddr, message)
+	})
+}
+
+// proveNFTOwnership runs the ledger's challenge-response check when it has
+// one. Ledgers without it only check that address owns the NFT.
+func proveNFTOwnership(l ledger.Ledger, address string, nftId string, sign func(message string) string) bool {
+	if prover, ok := l.(ledger.Prover); ok {
+		if err := prover.Prove(nftId, address, sign); err != nil {
+			log.Error(fmt.Sprintf("Possession proof failed: %v", err))
+			return false
+		}
+		log.Info("NFT possession proved")
+		return true
+	}
+
+	owned, err := l.Verify(nftId, address)
+	if err != nil {
+		log.Error(fmt.Sprintf("Error verifying NFT owner: %v", err))
+		return false
+	}
+	if !owned {
+		log.Error("NFT is not owned by the wallet")
+		return false
+	}
+	log.Info("NFT ownership verified")
+	return true
+}
+
+// signWithWallet has the user's wallet sign message through the socket
+// server. The auth wallet endpoint wraps the signature in a JSON object and
+// the request wallet endpoint returns it as plain text.
+func signWithWallet(endpoint string, addressField string, address string, message string) string {
+	SocketServerUrl := "http://localhost:10081" //os.Getenv("SOCKET_SERVER_URL")
+	payload, err := json.Marshal(map[string]string{addressField: address, "nft_id": message})
+	if err != nil {
+		log.Error(fmt.Sprintf("Error marshalling JSON: %v", err))
+		return ""
+	}
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	statusCode, responseBody, err := MakeAPICall("POST", SocketServerUrl+endpoint, headers, payload)
+	if err != nil {
+		log.Error(fmt.Sprintf("Error making API call: %v", err))
+		return ""
+	}
+	if statusCode != http.StatusOK {
+		log.Error(fmt.Sprintf("API call failed with status: %d, response: %s", statusCode, responseBody))
+		return ""
+	}
+
+	var response map[string]string
+	if err := json.Unmarshal(responseBody, &response); err == nil {
+		return strings.TrimSpace(response["response"])
+	}
+	return strings.TrimSpace(string(responseBody))
+}
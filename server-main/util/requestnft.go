@@ -4,41 +4,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"nexasecure/ledger"
 )
 
-//Mint new Req nft
-func MintReqNFT(ReqPubAddr string) string {
-	ReqBlockchainUrl := "http://localhost:18085"//os.Getenv("REQ_BLOCKCHAIN_URL")
-	endpoint := "/reqnft/create"
-	url := ReqBlockchainUrl + endpoint
-	payload := map[string]string{"owner": ReqPubAddr}
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		log.Error(fmt.Sprintf("Error marshalling JSON: %v", err))
-		return ""
-	}
-	headers := map[string]string{
-		"Content-Type": "application/json",
-	}
-	statusCode, responseBody, err := MakeAPICall("POST", url, headers, jsonData)
-	if err != nil {
-		log.Error(fmt.Sprintf("Error making API call: %v", err))
-		return ""
-	}
-	if statusCode != http.StatusOK && statusCode != http.StatusCreated  {
-		log.Error(fmt.Sprintf("API call failed with status: %d, response: %s", statusCode, responseBody))
-		return ""
+//Mint new Req nft, linked to the AuthNFT burn behind it when there is one
+func MintReqNFT(ReqPubAddr string, authBurn ledger.BurnProof) string {
+	var newNFT string
+	var err error
+	if minter, ok := reqLedger.(ledger.LinkedMinter); ok && authBurn != nil {
+		newNFT, err = minter.MintLinked(ReqPubAddr, authBurn)
+	} else {
+		newNFT, err = reqLedger.Mint(ReqPubAddr, nil)
 	}
-	var response map[string]string
-	err = json.Unmarshal(responseBody, &response)
 	if err != nil {
-		log.Error(fmt.Sprintf("Error unmarshalling JSON: %v", err))
-		return ""
-	}
-	// fmt.Println("response", response)
-	newNFT, exists := response["nft_id"]
-	if !exists {
-		log.Error("newnft not found in response")
+		log.Error(fmt.Sprintf("Error minting req NFT: %v", err))
 		return ""
 	}
 	log.Info("Req NFT successfully minted")
@@ -204,61 +183,3 @@ func RemoveReqNFTfromUser(reqPubAddr string) bool {
 	log.Info("AuthNFT successfully removed from user")
 	return true
 }
-
-func ValidateReqNFT(authPubAddr string, authNFT string) bool {
-	SocketServerUrl := "http://localhost:18085"//os.Getenv("SOCKET_SERVER_URL")
-	ApiEndpoint := "/reqnft/validate"
-	url := SocketServerUrl + ApiEndpoint
-	payload := map[string]string{
-		"address": authPubAddr, 
-		"nft_id": authNFT,
-	}
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		log.Error(fmt.Sprintf("Error marshalling JSON: %v", err))
-		return false
-	}
-	// fmt.Println(payload)
-	// fmt.Println(jsonData)
-	headers := map[string]string{
-		"Content-Type": "application/json",
-	}
-	//fmt.Println("send api call  to validate nft to url", url)
-	statusCode, responseBody, err := MakeAPICall("POST", url, headers, jsonData)
-	if err != nil {
-		log.Error(fmt.Sprintf("Error making API call: %v", err))
-		return false
-	}
-	// fmt.Println(responseBody)
-	if statusCode != http.StatusOK {
-		log.Error(fmt.Sprintf("API call failed with status: %d, response: %s", statusCode, responseBody))
-		return false
-	}	
-	var response map[string]interface{}
-	err = json.Unmarshal(responseBody, &response)
-	fmt.Println("response", response)
-	if err != nil {
-		log.Error(fmt.Sprintf("Error unmarshalling JSON: %v", err))
-		return false
-	}
-	fmt.Println("response", response)
-	if errorObj, exists := response["error"]; exists {
-		log.Error(fmt.Sprintf("API response contains error: %v", errorObj))
-		return false
-	}
-
-	valid, exists := response["valid"]
-	if !exists {
-		log.Error("valid not found in response")
-		return false
-	}
-
-	isValid, ok := valid.(bool)
-	if !ok {
-		log.Error("valid field is not of type bool")
-		return false
-	}
-
-	log.Info("AuthNFT successfully verified")
-	return isValid
-}
\ No newline at end of file
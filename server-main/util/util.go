@@ -5,8 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"nexasecure/logger"
-
+	"time"
 )
 
 // MakeAPICall sends an HTTP request and returns the status code, response body, and error if any.
@@ -43,44 +42,48 @@ func ManageNFTforLogin(Username string) bool {
         return false
     }
 
-    config := logger.NewConfigFromEnv()
-
-    // Initialize logger
-    log, err := logger.NewLogger(config)
-    if err != nil {
-        fmt.Printf("Failed to initialize logger: %v\n", err)
-        return false
-    }
-    defer log.Sync()
-
+    step := time.Now()
     AuthPubAddr := GetUserAuthPubAddr(Username)
+    observeStep("get_auth_pub_addr", step, AuthPubAddr != "")
     if AuthPubAddr == "" {
         log.Error(fmt.Sprintf("Failed to retrieve AuthPubAddr for user: %s", Username))
+        loginFlows.Inc("failed")
         return false
     }
 
+    step = time.Now()
     AuthNft := GetAuthNFT(AuthPubAddr)
+    observeStep("get_auth_nft", step, AuthNft != "")
     if AuthNft == "" {
         log.Error(fmt.Sprintf("Failed to retrieve AuthNFT for user: %s", Username))
+        loginFlows.Inc("failed")
         return false
     }
 
     // AuthNft := "nft-1746515757065257410"
     // AuthPubAddr := "3059301306072a8648ce3d020106082a8648ce3d03010703420004fb78b4a65dde4c6f6aff0cf6f9db210fcac1e8d3aaba1181b4dc4ab8c4065c533ea69023479bb8b1e9daecb817738c3e8368081e5c6c364abdf584a49770d068"
 
-    isVerified := ValidateAuthNFT(AuthPubAddr, AuthNft)
+    step = time.Now()
+    isVerified := ProveAuthNFT(AuthPubAddr, AuthNft)
+    observeStep("validate_auth_nft", step, isVerified)
     if !isVerified {
         log.Error(fmt.Sprintf("Auth NFT verification failed for user: %s", Username))
+        loginFlows.Inc("failed")
         return false
     }
     log.Info(fmt.Sprintf("Auth NFT verified successfully for user: %s", Username))
 
-    isRemovedAuthNFT := RemoveAuthNFTfromUser(AuthPubAddr)
-    if !isRemovedAuthNFT {
-        log.Error(fmt.Sprintf("Failed to remove Auth NFT from user: %s", Username))
+    // Look up everything the mint needs before burning, so a failure here
+    // leaves the user's AuthNFT untouched.
+    step = time.Now()
+    reqpubaddr := GetUserReqPubAddr(Username)
+    observeStep("get_req_pub_addr", step, reqpubaddr != "")
+    if reqpubaddr == "" {
+        log.Error(fmt.Sprintf("Failed to retrieve ReqPubAddr for user: %s", Username))
+        loginFlows.Inc("failed")
         return false
     }
-    log.Info(fmt.Sprintf("Auth NFT removed successfully for user: %s", Username))
+    fmt.Println("reqpubaddr", reqpubaddr)
 
     // // Revoke Auth NFT
 
@@ -97,27 +100,64 @@ func ManageNFTforLogin(Username string) bool {
     //     return false
     // }
 
-    reqpubaddr := GetUserReqPubAddr(Username)
-    if reqpubaddr == "" {
-        log.Error(fmt.Sprintf("Failed to retrieve ReqPubAddr for user: %s", Username))
+    // Burn the Auth NFT; the ReqNFT carries the proof of this login.
+    step = time.Now()
+    authBurn, isBurned := BurnAuthNFT(AuthPubAddr, AuthNft)
+    observeStep("burn_auth_nft", step, isBurned)
+    if !isBurned {
+        log.Error(fmt.Sprintf("Failed to burn Auth NFT for user: %s", Username))
+        loginFlows.Inc("failed")
         return false
     }
-    fmt.Println("reqpubaddr", reqpubaddr)
 
-    NewReqNFT := MintReqNFT(reqpubaddr)
+    step = time.Now()
+    NewReqNFT := MintReqNFT(reqpubaddr, authBurn)
+    observeStep("mint_req_nft", step, NewReqNFT != "")
     if NewReqNFT == "" {
         log.Error(fmt.Sprintf("Failed to mint ReqNFT for user: %s", Username))
+        // The AuthNFT is gone; give the user a new one so they can log in again.
+        if !ReissueAuthNFT(AuthPubAddr) {
+            log.Error(fmt.Sprintf("Failed to re-issue Auth NFT for user: %s", Username))
+        }
+        loginFlows.Inc("failed")
         return false
     }
     fmt.Println("NewReqNFT", NewReqNFT)
 
+    // The burned AuthNFT is only a stale ID in the wallet now, so failing to
+    // clear it does not fail the login.
+    step = time.Now()
+    isRemovedAuthNFT := RemoveAuthNFTfromUser(AuthPubAddr)
+    observeStep("remove_auth_nft", step, isRemovedAuthNFT)
+    if !isRemovedAuthNFT {
+        log.Warn(fmt.Sprintf("Failed to remove Auth NFT from user: %s", Username))
+    } else {
+        log.Info(fmt.Sprintf("Auth NFT removed successfully for user: %s", Username))
+    }
+
+    step = time.Now()
     isStored := StoreReqNFT(reqpubaddr, NewReqNFT)
+    observeStep("store_req_nft", step, isStored)
     if !isStored {
         log.Error(fmt.Sprintf("Error storing REQ NFT for user: %s", Username))
+        if !ReissueAuthNFT(AuthPubAddr) {
+            log.Error(fmt.Sprintf("Failed to re-issue Auth NFT for user: %s", Username))
+        }
+        loginFlows.Inc("failed")
         return false
     }
     fmt.Println("isStored", isStored)
 
+    // Let logout retire the ReqNFT even if the wallet is unreachable then.
+    // Without the approval logout still works through the wallet.
+    step = time.Now()
+    isApproved := ApproveServerForReqNFT(reqpubaddr, NewReqNFT)
+    observeStep("approve_server", step, isApproved)
+    if !isApproved {
+        log.Warn(fmt.Sprintf("Server not approved to retire ReqNFT for user: %s", Username))
+    }
+
     log.Info(fmt.Sprintf("Initial Login successful for user: %s.", Username))
+    loginFlows.Inc("success")
     return true
 }
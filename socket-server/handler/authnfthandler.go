@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
-	"server/logger"
 	"server/util"
 	//"strings"
 )
@@ -17,15 +15,6 @@ Auth Blockchain NFT
 //store nft handler
 func StoreAuthNFTHandler(w http.ResponseWriter, r *http.Request) {
 
-	config := logger.NewConfigFromEnv()
-
-    // Initialize logger
-    log, err := logger.NewLogger(config)
-    if err != nil {
-        fmt.Printf("Failed to initialize logger: %v\n", err)
-        os.Exit(1)
-    }
-    defer log.Sync()
 
 	var Auth struct{
 		AuthWallPubAddr string `json:"authwallPubAddr"`
@@ -67,13 +56,6 @@ func StoreAuthNFTHandler(w http.ResponseWriter, r *http.Request) {
 //get nft handler
 func GetAuthNFTHandler(w http.ResponseWriter, r *http.Request) {
 	//fmt.Println("GetAuthNFTHandler called")
-	config := logger.NewConfigFromEnv()
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-	defer log.Sync()
 
 	var Auth struct {
 		AuthWallPubAddr string `json:"authwallPubAddr"`
@@ -114,15 +96,6 @@ func GetAuthNFTHandler(w http.ResponseWriter, r *http.Request) {
 
 //remove nft handler
 func RemoveAuthNFTHandler(w http.ResponseWriter, r *http.Request) {
-	config := logger.NewConfigFromEnv()
-
-	// Initialize logger
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
 
 	var Auth struct {
 		AuthWallPubAddr string `json:"authwallPubAddr"`
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"server/logger"
 	"server/util"
 	"strings"
 	"sync"
@@ -18,6 +19,14 @@ var (
 	responsesMu     sync.Mutex
 )
 
+// log is the service logger. main injects it with SetLogger at startup.
+var log = logger.NewNop()
+
+// SetLogger sets the logger used by the handler package.
+func SetLogger(l logger.Logger) {
+	log = l
+}
+
 func HandleTCPConnection(conn net.Conn) {
 	defer func() {
 		clientsMu.Lock()
@@ -52,6 +61,8 @@ func HandleTCPConnection(conn net.Conn) {
 	clientsMu.Lock()
 	clients[conn.RemoteAddr().String()] = conn
 	clientsMu.Unlock()
+	connectedClients.Inc()
+	defer connectedClients.Dec()
 
 	// Setup client-specific response channel
 	clientAddr := conn.RemoteAddr().String()
@@ -86,7 +97,19 @@ func HandleTCPConnection(conn net.Conn) {
 	}
 }
 
-func SendMessageToClient(clientAddr string, message string) (string, error) {
+func SendMessageToClient(clientAddr string, message string) (response string, err error) {
+	command := strings.Fields(message + " ")[0]
+	start := time.Now()
+	defer func() {
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
+		} else if response == "No response from client" {
+			outcome = "timeout"
+		}
+		roundTrip.ObserveSince(start, command, outcome)
+	}()
+
 	clientsMu.Lock()
 	conn, exists := clients[clientAddr]
 	clientsMu.Unlock()
@@ -105,7 +128,7 @@ func SendMessageToClient(clientAddr string, message string) (string, error) {
 	}
 
 	// Send message
-	_, err := conn.Write([]byte(message + "\n"))
+	_, err = conn.Write([]byte(message + "\n"))
 	if err != nil {
 		return "", fmt.Errorf("failed to send message: %v", err)
 	}
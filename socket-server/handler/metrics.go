@@ -0,0 +1,14 @@
+package handler
+
+import (
+	"server/metrics"
+)
+
+// Socket server metrics exposed on /metrics.
+var (
+	connectedClients = metrics.NewGaugeVec("socket_connected_clients",
+		"Wallet clients with a validated TCP connection.")
+	roundTrip = metrics.NewHistogramVec("socket_round_trip_seconds",
+		"Time from sending a command to a wallet client until its reply, by command and outcome.",
+		[]float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 15}, "command", "outcome")
+)
@@ -2,10 +2,7 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"os"
-	"server/logger"
 	"server/util"
 )
 
@@ -16,15 +13,6 @@ Req Blockchain NFT
 //store nft handler
 func StoreReqNFTHandler(w http.ResponseWriter, r *http.Request) {
 
-	config := logger.NewConfigFromEnv()
-
-    // Initialize logger
-    log, err := logger.NewLogger(config)
-    if err != nil {
-        fmt.Printf("Failed to initialize logger: %v\n", err)
-        os.Exit(1)
-    }
-    defer log.Sync()
 	//log.Info("NFT stored in ")
 	var req struct {
 		RequestWallPubAddr string `json:"requestwallPubAddr"`
@@ -66,15 +54,6 @@ func StoreReqNFTHandler(w http.ResponseWriter, r *http.Request) {
 }
 //get nft handler
 func GetReqNFTHandler(w http.ResponseWriter, r *http.Request) {
-	config := logger.NewConfigFromEnv()
-
-	// Initialize logger
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
 	log.Info("NFT retrieved from ")
 
 	if r.Method != http.MethodPost {
@@ -126,15 +105,6 @@ func GetReqNFTHandler(w http.ResponseWriter, r *http.Request) {
 
 //remove nft handler
 func RemoveReqNFTHandler(w http.ResponseWriter, r *http.Request) {
-	config := logger.NewConfigFromEnv()
-
-	// Initialize logger
-	log, err := logger.NewLogger(config)
-	if err != nil {
-		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer log.Sync()
 
 	if r.Method != http.MethodPost {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
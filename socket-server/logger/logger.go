@@ -5,7 +5,9 @@ package logger
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -30,8 +32,14 @@ type ZapLogger struct {
 
 // Config holds logging configuration parameters.
 type Config struct {
-	Level  string // Log level (debug, info, warn, error, fatal)
-	Format string // Log format (json, console)
+	Level      string        // Log level (debug, info, warn, error, fatal)
+	Format     string        // Log format (json, console)
+	BaseDir    string        // Directory for rotated log files; empty logs to stdout only
+	RotateTime time.Duration // Log rotation interval
+	MaxSize    int64         // Rotate once the active file reaches this many bytes (0 disables)
+	MaxAge     time.Duration // Delete rotated files older than this (0 keeps them)
+	MaxBackups int           // Keep at most this many rotated files (0 keeps all)
+	Compress   bool          // Gzip rotated files
 }
 
 // NewLogger creates a new Logger instance with specified configuration.
@@ -39,6 +47,19 @@ func NewLogger(config Config) (Logger, error) {
 	logLevel := getZapLevel(config.Level)
 	encoderConfig := getEncoderConfig(config.Format)
 
+	if config.BaseDir != "" {
+		if err := os.MkdirAll(config.BaseDir, os.ModePerm); err != nil {
+			return nil, fmt.Errorf("failed to create base directory: %w", err)
+		}
+		// The rotating writer also echoes every entry to the console.
+		encoder := zapcore.NewConsoleEncoder(encoderConfig)
+		if config.Format == "json" {
+			encoder = zapcore.NewJSONEncoder(encoderConfig)
+		}
+		core := zapcore.NewCore(encoder, zapcore.AddSync(newRotatingFileWriter(config)), logLevel)
+		return &ZapLogger{delegate: zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)).Sugar()}, nil
+	}
+
 	zapConfig := zap.Config{
 		Level:             zap.NewAtomicLevelAt(logLevel),
 		Development:       false,
@@ -61,8 +82,17 @@ func NewLogger(config Config) (Logger, error) {
 	return &ZapLogger{delegate: zapLogger.Sugar()}, nil
 }
 
+// NewNop returns a Logger that discards everything. Packages use it until
+// the service injects the real logger at startup.
+func NewNop() Logger {
+	return &ZapLogger{delegate: zap.NewNop().Sugar()}
+}
+
 // NewConfigFromEnv creates Config from environment variables:
-// LOG_LEVEL (default: info), LOG_FORMAT (default: console)
+// LOG_LEVEL (default: info), LOG_FORMAT (default: console), BASE_DIR
+// (default: none, log to stdout only), LOG_ROTATE_MINUTES (default: 10),
+// LOG_MAX_SIZE_MB (default: 100), LOG_MAX_AGE_DAYS (default: 0, keep all),
+// LOG_MAX_BACKUPS (default: 0, unlimited) and LOG_COMPRESS (default: true)
 func NewConfigFromEnv() Config {
 	level := os.Getenv("LOG_LEVEL")
 	if level == "" {
@@ -75,9 +105,22 @@ func NewConfigFromEnv() Config {
 	}
 
 	return Config{
-		Level:  level,
-		Format: format,
+		Level:      level,
+		Format:     format,
+		BaseDir:    os.Getenv("BASE_DIR"),
+		RotateTime: time.Duration(envInt("LOG_ROTATE_MINUTES", 10)) * time.Minute,
+		MaxSize:    int64(envInt("LOG_MAX_SIZE_MB", 100)) << 20,
+		MaxAge:     time.Duration(envInt("LOG_MAX_AGE_DAYS", 0)) * 24 * time.Hour,
+		MaxBackups: envInt("LOG_MAX_BACKUPS", 0),
+		Compress:   os.Getenv("LOG_COMPRESS") != "false",
+	}
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v >= 0 {
+		return v
 	}
+	return def
 }
 
 func getZapLevel(level string) zapcore.Level {
@@ -0,0 +1,243 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+func getLogFilePath(baseDir string) string {
+	date := time.Now().Format("2006-01-02")
+	timePart := time.Now().Format("15-04")
+	dateDir := filepath.Join(baseDir, date)
+	if err := os.MkdirAll(dateDir, os.ModePerm); err != nil {
+		fmt.Printf("failed to create date directory: %v\n", err)
+	}
+
+	// Size-based rotation can roll over more than once a minute, so never
+	// reopen a file that was already rotated out.
+	path := filepath.Join(dateDir, fmt.Sprintf("log-%s.log", timePart))
+	for i := 1; fileExists(path) || fileExists(path+".gz"); i++ {
+		path = filepath.Join(dateDir, fmt.Sprintf("log-%s.%d.log", timePart, i))
+	}
+	return path
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// rotatingFileWriter writes to one log file at a time and rolls over to a
+// new file when the rotation interval elapses or the size limit is reached.
+// Rolled files are optionally gzipped and pruned by age and count.
+type rotatingFileWriter struct {
+	mu         sync.Mutex
+	baseDir    string
+	rotateTime time.Duration
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
+
+	lastRotate time.Time
+	file       *os.File
+	size       int64
+
+	// archiveMu serializes background compression and pruning so that
+	// concurrent rotations never race over the same files.
+	archiveMu sync.Mutex
+}
+
+func newRotatingFileWriter(config Config) *rotatingFileWriter {
+	return &rotatingFileWriter{
+		baseDir:    config.BaseDir,
+		rotateTime: config.RotateTime,
+		maxSize:    config.MaxSize,
+		maxAge:     config.MaxAge,
+		maxBackups: config.MaxBackups,
+		compress:   config.Compress,
+	}
+}
+
+func (w *rotatingFileWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	// Write to the file
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+	// Also write to the console
+	fmt.Print(string(p))
+	return n, nil
+}
+
+func (w *rotatingFileWriter) shouldRotate(next int) bool {
+	if w.file == nil {
+		return true
+	}
+	if w.rotateTime > 0 && time.Since(w.lastRotate) >= w.rotateTime {
+		return true
+	}
+	return w.maxSize > 0 && w.size > 0 && w.size+int64(next) > w.maxSize
+}
+
+// rotate closes the active file, opens a fresh one and hands the old file
+// to the background archiver. Callers must hold w.mu.
+func (w *rotatingFileWriter) rotate() error {
+	var previous string
+	if w.file != nil {
+		previous = w.file.Name()
+		w.file.Close()
+		w.file = nil
+	}
+
+	file, err := os.OpenFile(getLogFilePath(w.baseDir), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.lastRotate = time.Now()
+
+	if previous != "" {
+		go w.archive(previous)
+	}
+	return nil
+}
+
+// archive compresses a rotated file and applies the retention policy.
+func (w *rotatingFileWriter) archive(rotated string) {
+	w.archiveMu.Lock()
+	defer w.archiveMu.Unlock()
+
+	if w.compress {
+		if err := gzipFile(rotated); err != nil {
+			fmt.Printf("failed to compress log file %s: %v\n", rotated, err)
+		}
+	}
+	if err := w.prune(); err != nil {
+		fmt.Printf("failed to prune log files: %v\n", err)
+	}
+}
+
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// rotatedName matches the names getLogFilePath gives log files.
+var rotatedName = regexp.MustCompile(`^log-\d{2}-\d{2}(\.\d+)?\.log(\.gz)?$`)
+
+// isRotatedLog reports whether path is a file this writer created under
+// baseDir: <date>/log-HH-MM[.N].log, optionally gzipped.
+func isRotatedLog(baseDir, path string) bool {
+	dateDir := filepath.Dir(path)
+	if filepath.Dir(dateDir) != filepath.Clean(baseDir) {
+		return false
+	}
+	if _, err := time.Parse("2006-01-02", filepath.Base(dateDir)); err != nil {
+		return false
+	}
+	return rotatedName.MatchString(filepath.Base(path))
+}
+
+type logFile struct {
+	path    string
+	modTime time.Time
+}
+
+// prune deletes rotated files past maxAge and all but the newest maxBackups.
+// Only files named by getLogFilePath are considered, and the file currently
+// being written is never removed.
+func (w *rotatingFileWriter) prune() error {
+	if w.maxAge <= 0 && w.maxBackups <= 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	var active string
+	if w.file != nil {
+		active = w.file.Name()
+	}
+	w.mu.Unlock()
+
+	var rotated []logFile
+	err := filepath.Walk(w.baseDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || path == active {
+			return nil
+		}
+		if isRotatedLog(w.baseDir, path) {
+			rotated = append(rotated, logFile{path: path, modTime: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(rotated, func(i, j int) bool { return rotated[i].modTime.After(rotated[j].modTime) })
+	cutoff := time.Now().Add(-w.maxAge)
+	for i, f := range rotated {
+		expired := w.maxAge > 0 && f.modTime.Before(cutoff)
+		excess := w.maxBackups > 0 && i >= w.maxBackups
+		if expired || excess {
+			os.Remove(f.path)
+			// Drop the date directory once it has no files left.
+			os.Remove(filepath.Dir(f.path))
+		}
+	}
+	return nil
+}
+
+func (w *rotatingFileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file != nil {
+		return w.file.Sync()
+	}
+	return nil
+}
@@ -4,12 +4,25 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"server/handler"
+	"server/logger"
+	"server/metrics"
+	"server/util"
 )
 
 
 
 func main() {
+	log, err := logger.NewLogger(logger.NewConfigFromEnv())
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+	handler.SetLogger(log)
+	util.SetLogger(log)
+
 	fmt.Println("Socket Server starting...")
 
 	// Start the TCP server
@@ -17,14 +30,15 @@ func main() {
 
 	// Start the HTTP server
 	// http.HandleFunc("/send", handler.SendMessageHandler)
-	http.HandleFunc("/setauthnft", handler.StoreAuthNFTHandler)
-	http.HandleFunc("/getauthnft", handler.GetAuthNFTHandler)
-	http.HandleFunc("/removeauthnft", handler.RemoveAuthNFTHandler)
-	http.HandleFunc("/setreqnft", handler.StoreReqNFTHandler)
-	http.HandleFunc("/getreqnft", handler.GetReqNFTHandler)
-	http.HandleFunc("/removereqnft", handler.RemoveReqNFTHandler)
-	http.HandleFunc("/signauthwallet", handler.SignAuthwalletHandler)
-	http.HandleFunc("/signreqwallet", handler.SignReqwalletHandler)
+	http.HandleFunc("/setauthnft", metrics.InstrumentHandler("/setauthnft", handler.StoreAuthNFTHandler))
+	http.HandleFunc("/getauthnft", metrics.InstrumentHandler("/getauthnft", handler.GetAuthNFTHandler))
+	http.HandleFunc("/removeauthnft", metrics.InstrumentHandler("/removeauthnft", handler.RemoveAuthNFTHandler))
+	http.HandleFunc("/setreqnft", metrics.InstrumentHandler("/setreqnft", handler.StoreReqNFTHandler))
+	http.HandleFunc("/getreqnft", metrics.InstrumentHandler("/getreqnft", handler.GetReqNFTHandler))
+	http.HandleFunc("/removereqnft", metrics.InstrumentHandler("/removereqnft", handler.RemoveReqNFTHandler))
+	http.HandleFunc("/signauthwallet", metrics.InstrumentHandler("/signauthwallet", handler.SignAuthwalletHandler))
+	http.HandleFunc("/signreqwallet", metrics.InstrumentHandler("/signreqwallet", handler.SignReqwalletHandler))
+	http.Handle("/metrics", metrics.Handler())
 	// http.HandleFunc("/login", handler.LoginHandle)
 	// http.HandleFunc("/signmsg", handler.SignMsgHandler)
 	fmt.Println("HTTP API listening on port 10081")
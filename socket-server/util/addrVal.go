@@ -3,13 +3,19 @@ package util
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"server/logger"
 )
 
+// log is the service logger. main injects it with SetLogger at startup.
+var log = logger.NewNop()
+
+// SetLogger sets the logger used by the util package.
+func SetLogger(l logger.Logger) {
+	log = l
+}
+
 // MakeAPICall sends an HTTP request and returns the status code, response body, and error if any.
 func MakeAPICall(method, url string, headers map[string]string, body []byte) (int, []byte, error) {
     req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
@@ -38,15 +44,6 @@ func MakeAPICall(method, url string, headers map[string]string, body []byte) (in
 
 //validate the request blockchain wallet private key
 func ValidateReqaddr(reqaddr string) bool {
-    config := logger.NewConfigFromEnv()
-
-    // Initialize logger
-    log, err := logger.NewLogger(config)
-    if err != nil {
-        fmt.Printf("Failed to initialize logger: %v\n", err)
-        os.Exit(1)
-    }
-    defer log.Sync()
 
     requestaddrvfalidaton := "http://localhost:18085/reqwallet/pubaddrval"
 
@@ -86,15 +83,6 @@ func ValidateReqaddr(reqaddr string) bool {
 
 //validate the auth blockchain wallet public key
 func ValidateAuthaddr(authaddr string) bool {
-    config := logger.NewConfigFromEnv()
-
-    // Initialize logger
-    log, err := logger.NewLogger(config)
-    if err != nil {
-        fmt.Printf("Failed to initialize logger: %v\n", err)
-        os.Exit(1)
-    }
-    defer log.Sync()
 
     authpubaddrvalidation := "http://localhost:18080/authwallet/pubaddrval"
 